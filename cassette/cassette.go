@@ -0,0 +1,232 @@
+// Package cassette records HTTP responses from real services to a file (a
+// "cassette") and replays them, so that API clients can be tested without
+// network access or credentials.
+//
+// Cassettes are replayed by default. To record new cassettes, set the
+// environment variable OATS_RECORD=1 (and any credentials the tests need) and
+// run the tests; each cassette is overwritten with the responses received.
+// Request headers are never saved, and query parameters named in Redact
+// (emails and keys) are replaced before saving, so cassettes can be committed.
+//
+// The cassettes currently in the repository were written by hand, not
+// recorded: they follow each service's response format, but their values
+// (including ScholarSphere IDs) are made up, and they only have the response
+// headers the clients use. Tests assert these values, so re-recording a
+// cassette requires updating its tests to match the live responses.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// RecordEnv is the environment variable that turns on recording
+const RecordEnv = "OATS_RECORD"
+
+// redacted replaces the values of redacted query parameters
+const redacted = "REDACTED"
+
+// Redact lists query parameters whose values are not saved in cassettes
+var Redact = []string{"email", "mailto", "key", "api_key", "apikey"}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Body     string      `json:"body,omitempty"` // request body
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Response string      `json:"response"` // response body
+}
+
+// Cassette is an http.RoundTripper that records or replays Interactions
+type Cassette struct {
+	// Transport is used to make real requests when recording (default:
+	// http.DefaultTransport)
+	Transport http.RoundTripper
+
+	path         string
+	record       bool
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Recording returns whether cassettes are being recorded rather than
+// replayed.
+func Recording() bool {
+	return os.Getenv(RecordEnv) != ""
+}
+
+// Load returns the Cassette for the file at path. When replaying, the file
+// must exist. When recording, the file is replaced by Save.
+func Load(path string) (*Cassette, error) {
+	c := &Cassette{path: path, record: Recording()}
+	if c.record {
+		return c, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w (set %s=1 to record it)", err, RecordEnv)
+	}
+	if err := json.Unmarshal(b, &c.interactions); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// New returns the Cassette testdata/name.json for the test. The cassette is
+// saved when the test finishes.
+func New(t testing.TB, name string) *Cassette {
+	t.Helper()
+	c, err := Load(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Save(); err != nil {
+			t.Error(err)
+		}
+	})
+	return c
+}
+
+// Client returns an http.Client that uses the Cassette
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// RoundTrip implements http.RoundTripper. When replaying, it returns the
+// first unused Interaction with the same method, URL, and body, or the last
+// matching Interaction if all have been used.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := Interaction{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Body:   string(body),
+	}
+	if c.record {
+		return c.recordTrip(req, key)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := -1
+	for i, in := range c.interactions {
+		if in.Method != key.Method || in.URL != key.URL || in.Body != key.Body {
+			continue
+		}
+		found = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("cassette %s: no response for %s %s", c.path, key.Method, key.URL)
+	}
+	c.used[found] = true
+	return c.interactions[found].response(req), nil
+}
+
+// recordTrip makes the request and saves the response
+func (c *Cassette) recordTrip(req *http.Request, in Interaction) (*http.Response, error) {
+	tr := c.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	in.Status = resp.StatusCode
+	in.Header = resp.Header.Clone()
+	in.Header.Del("Set-Cookie")
+	in.Response = string(b)
+	c.mu.Lock()
+	c.interactions = append(c.interactions, in)
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+	return in.response(req), nil
+}
+
+// Save writes recorded Interactions to the cassette file. It does nothing
+// when replaying.
+func (c *Cassette) Save() error {
+	if !c.record {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.interactions) == 0 {
+		return errors.New("cassette: nothing recorded for " + c.path)
+	}
+	b, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(b, '\n'), 0644)
+}
+
+// response returns the Interaction's response for req
+func (in Interaction) response(req *http.Request) *http.Response {
+	header := in.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(in.Response))),
+		ContentLength: int64(len(in.Response)),
+		Request:       req,
+	}
+}
+
+// redactURL returns u as a string with values of redacted query parameters
+// replaced.
+func redactURL(u *url.URL) string {
+	cp := *u
+	q := cp.Query()
+	changed := false
+	for _, p := range Redact {
+		if _, ok := q[p]; ok {
+			q.Set(p, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		cp.RawQuery = q.Encode()
+	}
+	return cp.String()
+}
@@ -0,0 +1,94 @@
+package cassette_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/psu-libraries/oats/cassette"
+)
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %s %d", r.Method, r.URL.Path, calls)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	// record
+	t.Setenv(cassette.RecordEnv, "1")
+	rec, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := rec.Client()
+	for _, p := range []string{"/a?email=me@example.com", "/a?email=me@example.com", "/b"} {
+		if _, err := get(cli, srv.URL+p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "me@example.com") {
+		t.Error("cassette includes email")
+	}
+
+	// replay
+	t.Setenv(cassette.RecordEnv, "")
+	rep, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli = rep.Client()
+	expect := map[string]string{
+		"/b":                       "GET /b 3",
+		"/a?email=you@example.com": "GET /a 1",
+		"/a?email=me@example.com":  "GET /a 2",
+		"/a?email=any@example.com": "GET /a 2", // all used: repeats last
+	}
+	for _, p := range []string{"/b", "/a?email=you@example.com", "/a?email=me@example.com", "/a?email=any@example.com"} {
+		body, err := get(cli, srv.URL+p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != expect[p] {
+			t.Errorf("%s: expected %q, got %q", p, expect[p], body)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls to server, got %d", calls)
+	}
+	if _, err := get(cli, srv.URL+"/c"); err == nil {
+		t.Error("expected error for request not in cassette")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Setenv(cassette.RecordEnv, "")
+	if _, err := cassette.Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing cassette")
+	}
+}
+
+func get(cli *http.Client, u string) (string, error) {
+	resp, err := cli.Get(u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
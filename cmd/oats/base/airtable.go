@@ -1,8 +1,11 @@
 package base
 
-// This file includes utility functions for Airtable
+// This file includes utility functions for Airtable. The airtable package
+// doesn't support contexts, so requests in progress can't be canceled: the
+// functions here check the context before each request.
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mehanizm/airtable"
@@ -10,7 +13,10 @@ import (
 
 // GetRecord returns an airtable record associated with the given table name and
 // id.
-func (cmd *Oats) GetRecord(tableName string, id string) (*airtable.Record, error) {
+func (cmd *Oats) GetRecord(ctx context.Context, tableName string, id string) (*airtable.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	table := cmd.atClient.GetTable(cmd.AirtableBase(), tableName)
 	return table.GetRecord(id)
 }
@@ -19,7 +25,7 @@ func (cmd *Oats) GetRecord(tableName string, id string) (*airtable.Record, error
 // on the specified filter. The filter string should use the Airtable formula
 // syntax. The fields parameter can be used to specify columns in the returned
 // records
-func (cmd *Oats) GetRecordsFilterFields(tableName string, filter string, fields []string) ([]*airtable.Record, error) {
+func (cmd *Oats) GetRecordsFilterFields(ctx context.Context, tableName string, filter string, fields []string) ([]*airtable.Record, error) {
 	var (
 		offset  string
 		allRecs []*airtable.Record
@@ -33,6 +39,9 @@ func (cmd *Oats) GetRecordsFilterFields(tableName string, filter string, fields
 		cfg.ReturnFields(fields...)
 	}
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if offset != "" {
 			cfg.WithOffset(offset)
 		}
@@ -52,10 +61,15 @@ func (cmd *Oats) GetRecordsFilterFields(tableName string, filter string, fields
 // // _commitFunc is common signature of airtable post/put/patch functions
 type _commitFunc func(*airtable.Records) (*airtable.Records, error)
 
-// _commitRecords abstracts post/put/patch functions
-func (cmd *Oats) _commitRecords(recs []*airtable.Record, f _commitFunc) ([]*airtable.Record, error) {
+// _commitRecords abstracts post/put/patch functions. Records are committed in
+// batches of 10; if a batch fails or the context is done, the records
+// committed so far are returned with the error.
+func (cmd *Oats) _commitRecords(ctx context.Context, recs []*airtable.Record, f _commitFunc) ([]*airtable.Record, error) {
 	var responses []*airtable.Record
 	for i := 0; i < len(recs); i += 10 {
+		if err := ctx.Err(); err != nil {
+			return responses, err
+		}
 		end := i + 10
 		if end > len(recs) {
 			end = len(recs)
@@ -64,7 +78,7 @@ func (cmd *Oats) _commitRecords(recs []*airtable.Record, f _commitFunc) ([]*airt
 			Records: recs[i:end],
 		})
 		if err != nil {
-			return nil, err
+			return responses, err
 		}
 		responses = append(responses, resp.Records...)
 	}
@@ -72,10 +86,11 @@ func (cmd *Oats) _commitRecords(recs []*airtable.Record, f _commitFunc) ([]*airt
 }
 
 // PostRecords does POST for records in an Airtable. A POST request will create
-// new rows for each record.
-func (cmd *Oats) PostRecords(tableName string, records []*airtable.Record) ([]*airtable.Record, error) {
+// new rows for each record. If it fails part way, the records created so far
+// are returned with the error.
+func (cmd *Oats) PostRecords(ctx context.Context, tableName string, records []*airtable.Record) ([]*airtable.Record, error) {
 	table := cmd.atClient.GetTable(cmd.AirtableBase(), tableName)
-	return cmd._commitRecords(records, table.AddRecords)
+	return cmd._commitRecords(ctx, records, table.AddRecords)
 }
 
 type atIndex map[string][]*airtable.Record
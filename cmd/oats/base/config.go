@@ -11,6 +11,8 @@ import (
 // Config is the global configuration
 type Config struct {
 	Airtable struct {
+		// URL for the Airtable API (default: https://api.airtable.com/v0)
+		URL    string
 		APIKey string
 		Base   struct {
 			Production string
@@ -19,11 +21,16 @@ type Config struct {
 		TableName       string
 		Tasks           string
 		ActivityInsight string `yaml:"activity_insight"`
+		DOIReview       string `yaml:"doi_review"`
 	}
 	Unpaywall struct {
+		// URL for the Unpaywall API (default: https://api.unpaywall.org/v2)
+		URL   string
 		Email string
 	}
 	OpenAccessButton struct {
+		// URL for the Open Access Button API (default: https://api.openaccessbutton.org)
+		URL string
 		Key string
 	}
 	ScholarSphere struct {
@@ -36,7 +43,51 @@ type Config struct {
 		Production string
 		Test       string
 	} `yaml:"rmdb"`
+	CrossRef struct {
+		// URL for the CrossRef API (default: https://api.crossref.org)
+		URL string
+		// contact email sent with requests (CrossRef's "polite" pool)
+		Mailto string
+	} `yaml:"crossref"`
+	DOI struct {
+		// URL for doi.org's handle and RA APIs (default: https://doi.org)
+		URL string
+	} `yaml:"doi"`
+	DataCite struct {
+		// URL for the DataCite API (default: https://api.datacite.org)
+		URL string
+	} `yaml:"datacite"`
+	Cache struct {
+		// directory for cached API responses (default: user cache dir)
+		Dir string
+		// time to keep responses for each service, e.g., "168h"
+		TTL map[string]string
+	}
+	Email struct {
+		// From address for messages, e.g., "OA Team <oa@example.edu>"
+		From    string
+		ReplyTo string `yaml:"reply_to"`
+		// Domain for recipient addresses: username@domain (default: psu.edu)
+		Domain string
+		// Staff mailbox copied on deposit notices (optional)
+		Staff string
+		// SMTP server for sending messages; messages can also be saved to
+		// an outbox directory instead
+		SMTP struct {
+			Host     string // host:port
+			Username string
+			Password string
+		} `yaml:"smtp"`
+		// Templates for messages (optional; built-in templates are used by
+		// default)
+		Templates map[string]string
+	}
 	ArticlePath string `yaml:"article_path"`
+	Deposit     struct {
+		// Metadata maps optional deposit fields to an ordered list of
+		// sources ("crossref", "rmd") used to fill them.
+		Metadata map[string][]string
+	}
 }
 
 func loadConfig(file string) (*Config, error) {
@@ -1,6 +1,10 @@
 package base
 
-import "github.com/mehanizm/airtable"
+import (
+	"fmt"
+
+	"github.com/mehanizm/airtable"
+)
 
 // Oats represents primary application state: configuation an airtable client
 type Oats struct {
@@ -15,9 +19,15 @@ func NewOats(config string) (*Oats, error) {
 	if err != nil {
 		return nil, err
 	}
+	atClient := airtable.NewClient(cfg.Airtable.APIKey)
+	if cfg.Airtable.URL != "" {
+		if err := atClient.SetBaseURL(cfg.Airtable.URL); err != nil {
+			return nil, fmt.Errorf("airtable url in config: %w", err)
+		}
+	}
 	return &Oats{
 		Config:   cfg,
-		atClient: airtable.NewClient(cfg.Airtable.APIKey),
+		atClient: atClient,
 	}, nil
 }
 
@@ -0,0 +1,171 @@
+package cmd
+
+// Author reconciliation: CrossRef author lists are complete and include
+// ORCIDs, but only RMD knows which authors are PSU faculty. The functions here
+// align the two lists by normalised name so that ScholarSphere creators carry
+// both identifiers.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// personName is a name normalised for comparison: lower case, without
+// diacritics or punctuation.
+type personName struct {
+	family []string // family name parts, e.g. ["garcia", "marquez"]
+	given  []string // given names and initials, e.g. ["j", "robert"]
+}
+
+// newPersonName builds a personName from given and family names. If the family
+// name is empty, full is parsed as "Family, Given" or "Given Family".
+func newPersonName(given, family, full string) personName {
+	if family == "" && full != "" {
+		if i := strings.Index(full, ","); i >= 0 {
+			family, given = full[:i], full[i+1:]
+		} else if parts := strings.Fields(full); len(parts) > 0 {
+			family = parts[len(parts)-1]
+			given = strings.Join(parts[:len(parts)-1], " ")
+		}
+	}
+	return personName{
+		family: nameTokens(family),
+		given:  nameTokens(given),
+	}
+}
+
+// nameTokens splits a name on spaces, hyphens and periods after removing
+// diacritics, case, and other punctuation: "Jean-Luc O'Brien" -> [jean luc obrien]
+func nameTokens(name string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(stripMarks(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '.' || r == '‐':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// stripMarks removes diacritics: "García" -> "Garcia"
+func stripMarks(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if out, _, err := transform.String(t, s); err == nil {
+		return out
+	}
+	return s
+}
+
+func (n personName) empty() bool {
+	return len(n.family) == 0
+}
+
+// matchScore compares two names: 0 means no match, 1 means the family names
+// match and the given names are compatible (e.g., "J." and "John"), and 2
+// means the family and first given names match exactly.
+func (n personName) matchScore(o personName) int {
+	if n.empty() || o.empty() || !familyMatch(n.family, o.family) {
+		return 0
+	}
+	if len(n.given) == 0 || len(o.given) == 0 {
+		return 1
+	}
+	a, b := n.given[0], o.given[0]
+	if a == b {
+		return 2
+	}
+	if a[0] != b[0] {
+		return 0
+	}
+	if len(a) == 1 || len(b) == 1 || strings.HasPrefix(a, b) || strings.HasPrefix(b, a) {
+		return 1
+	}
+	return 0
+}
+
+// familyMatch is true if the family names are the same, ignoring hyphenation,
+// or if one is a hyphenated or compound part of the other.
+func familyMatch(a, b []string) bool {
+	if strings.Join(a, "") == strings.Join(b, "") {
+		return true
+	}
+	short, long := a, b
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) != 1 {
+		return false
+	}
+	for _, part := range long {
+		if part == short[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// swapped returns the name with given and family names reversed. CrossRef
+// sometimes has them in the wrong order.
+func (n personName) swapped() personName {
+	return personName{family: n.given, given: n.family}
+}
+
+// reconcileAuthors returns ScholarSphere Creators for a work using CrossRef
+// authors (for names, order and ORCIDs) and RMD contributors (for PSU IDs).
+// PSU contributors in RMD that could not be matched to a CrossRef author are
+// returned as unmatched. If there are no CrossRef authors, creators are based
+// on the RMD contributors alone.
+func reconcileAuthors(auths []crossref.Author, contribs []rmd.Contributor) ([]scholargo.Creator, []rmd.Contributor) {
+	creators := convertCrossRefAuthors(auths)
+	if len(creators) == 0 {
+		return convertRMDAuthors(contribs), nil
+	}
+	var names []personName
+	for _, a := range auths {
+		if a.Family == "" && a.Name == "" {
+			continue // skipped by convertCrossRefAuthors
+		}
+		names = append(names, newPersonName(a.Given, a.Family, a.Name))
+	}
+	var unmatched []rmd.Contributor
+	for _, c := range contribs {
+		if c.PSUID == "" {
+			continue
+		}
+		psuName := newPersonName(strings.Join([]string{c.FirstName, c.MiddleName}, " "), c.LastName, "")
+		best, bestScore := -1, 0
+		for i, n := range names {
+			if creators[i].PSUID != "" {
+				continue // already matched
+			}
+			score := n.matchScore(psuName)
+			if score == 0 {
+				score = n.swapped().matchScore(psuName)
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			unmatched = append(unmatched, c)
+			continue
+		}
+		creators[best].PSUID = c.PSUID
+	}
+	return creators, unmatched
+}
+
+// contributorName is used for reporting unmatched contributors
+func contributorName(c rmd.Contributor) string {
+	return fmt.Sprintf("%s %s (%s)", c.FirstName, c.LastName, c.PSUID)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/rmd"
+)
+
+func TestReconcileAuthors(t *testing.T) {
+	auths := []crossref.Author{
+		{Given: "José", Family: "García-Márquez", ORCID: "http://orcid.org/0000-0002-1825-0097"},
+		{Given: "A. B.", Family: "Smith"},
+		{Name: "Wei Zhang"},
+		{Given: "Li", Family: "Chen"}, // not at PSU
+		{Given: "Robert", Family: "Jones"},
+	}
+	contribs := []rmd.Contributor{
+		{FirstName: "Jose", LastName: "Garcia Marquez", PSUID: "jgm1"},
+		{FirstName: "Alice", MiddleName: "B", LastName: "Smith", PSUID: "abs2"},
+		{FirstName: "Zhang", LastName: "Wei", PSUID: "wz3"}, // swapped order
+		{FirstName: "Rob", LastName: "Jones", PSUID: "rj4"},
+		{FirstName: "Mary", LastName: "Brown", PSUID: "mb5"}, // not in crossref
+		{FirstName: "Li", LastName: "Chen"},                  // no PSU ID
+	}
+	creators, unmatched := reconcileAuthors(auths, contribs)
+	expect := []string{"jgm1", "abs2", "wz3", "", "rj4"}
+	if len(creators) != len(expect) {
+		t.Fatalf("expected %d creators, got %d", len(expect), len(creators))
+	}
+	for i, id := range expect {
+		if creators[i].PSUID != id {
+			t.Errorf("creator %s: expected PSU ID %q, got %q", creators[i].Name, id, creators[i].PSUID)
+		}
+	}
+	if creators[0].Orcid == "" {
+		t.Error("expected ORCID to be kept")
+	}
+	if len(unmatched) != 1 || unmatched[0].PSUID != "mb5" {
+		t.Errorf("expected mb5 to be unmatched, got %v", unmatched)
+	}
+}
+
+func TestReconcileAuthorsRMDOnly(t *testing.T) {
+	contribs := []rmd.Contributor{{FirstName: "Mary", LastName: "Brown", PSUID: "mb5"}}
+	creators, unmatched := reconcileAuthors(nil, contribs)
+	if len(creators) != 1 || creators[0].PSUID != "mb5" || len(unmatched) != 0 {
+		t.Errorf("unexpected result: %v, %v", creators, unmatched)
+	}
+}
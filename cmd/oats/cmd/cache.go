@@ -0,0 +1,153 @@
+package cmd
+
+// API responses are cached on disk so that reruns don't repeat lookups. Each
+// service has its own cache directory and TTL; TTLs can be changed in the
+// config file:
+//
+//  cache:
+//    dir: /path/to/cache
+//    ttl:
+//      crossref: 336h
+//      rmd: 1h
+//
+// A TTL of 0 disables caching for the service. The --no-cache flag disables
+// caching for all services, and "oats cache clear" removes cached responses.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/httpcache"
+)
+
+// cached services
+const (
+	SVC_CROSSREF  = "crossref"
+	SVC_DOI       = "doi"
+	SVC_UNPAYWALL = "unpaywall"
+	SVC_OABUTTON  = "oabutton"
+	SVC_RMD       = "rmd"
+)
+
+// defaultCacheTTL is used for services without a TTL in the config file. RMD
+// isn't cached by default because oats updates it. ScholarSphere isn't cached
+// at all: deposit checks its DOI list to avoid duplicate deposits, so the list
+// must be current.
+var defaultCacheTTL = map[string]time.Duration{
+	SVC_CROSSREF:  7 * 24 * time.Hour,
+	SVC_DOI:       24 * time.Hour,
+	SVC_UNPAYWALL: 24 * time.Hour,
+	SVC_OABUTTON:  24 * time.Hour,
+	SVC_RMD:       0,
+}
+
+// cacheDir returns the cache directory from the config file, or the default
+func cacheDir() (string, error) {
+	if oats.Cache.Dir != "" {
+		return oats.Cache.Dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no cache directory: %w", err)
+	}
+	return filepath.Join(dir, "oats"), nil
+}
+
+// cacheTTL returns the TTL for the service
+func cacheTTL(service string) (time.Duration, error) {
+	if val, ok := oats.Cache.TTL[service]; ok {
+		ttl, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cache TTL for %s: %w", service, err)
+		}
+		return ttl, nil
+	}
+	return defaultCacheTTL[service], nil
+}
+
+// checkCacheConfig returns an error if the cache settings in the config file
+// are invalid
+func checkCacheConfig() error {
+	for service := range oats.Cache.TTL {
+		if _, ok := defaultCacheTTL[service]; !ok {
+			return fmt.Errorf("unknown service in cache config: %s", service)
+		}
+		if _, err := cacheTTL(service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheTransport returns a caching transport for the service that sends
+// requests through next, or nil if caching is disabled.
+func cacheTransport(service string, next http.RoundTripper) http.RoundTripper {
+	if rootFlags.noCache {
+		return nil
+	}
+	ttl, err := cacheTTL(service)
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		log.Printf("⚠️ caching disabled: %s", err)
+		return nil
+	}
+	t := httpcache.New(filepath.Join(dir, service), ttl)
+	t.Transport = next
+	return t
+}
+
+var cacheCmd = &coral.Command{
+	Use:   "cache",
+	Short: "Manage cached API responses",
+}
+
+var cacheClearCmd = &coral.Command{
+	Use:   "clear [service...]",
+	Short: "Remove cached API responses",
+	Long: `The cache clear command removes cached API responses for the named services
+(crossref, doi, unpaywall, oabutton, rmd), or for all services if none are
+named. Only the services' directories in cache.dir are removed.`,
+	RunE: runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheClear(cmd *coral.Command, args []string) error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	services := args
+	if len(services) == 0 {
+		// only the services' directories are removed, in case cache.dir is
+		// shared with other files
+		for service := range defaultCacheTTL {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+	}
+	for _, service := range services {
+		if _, ok := defaultCacheTTL[service]; !ok {
+			return fmt.Errorf("unknown service: %s", service)
+		}
+	}
+	for _, service := range services {
+		if err := httpcache.Clear(filepath.Join(dir, service)); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		log.Printf("cleared %s", filepath.Join(dir, service))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheClear(t *testing.T) {
+	defer setTestOats()()
+	dir := t.TempDir()
+	oats.Cache.Dir = dir
+	for _, name := range []string{"crossref/ab/abc", "rmd/cd/cde", "other/file", "notes.txt"} {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	if err := runCacheClear(cacheClearCmd, []string{"unknown"}); err == nil {
+		t.Error("expected error for unknown service")
+	}
+	if err := runCacheClear(cacheClearCmd, []string{"rmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if exists("rmd") || !exists("crossref") {
+		t.Error("expected only the rmd cache to be cleared")
+	}
+	// all services: other files in the directory are kept
+	if err := runCacheClear(cacheClearCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if exists("crossref") || !exists("other/file") || !exists("notes.txt") {
+		t.Error("expected only service caches to be cleared")
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+// API clients are configured here using endpoints from the config file,
+// per-service rate limits, and the response cache (see cache.go).
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/datacite"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/oabutton"
+	"github.com/psu-libraries/oats/ratelimit"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+	"github.com/psu-libraries/oats/unpaywall"
+)
+
+// serviceLimiters limit requests to APIs that don't report their own limits.
+// CrossRef's client sets its limit from response headers and the Airtable
+// client has its own limit. Limiters are shared by all workers.
+var serviceLimiters = map[string]*ratelimit.Limiter{
+	SVC_UNPAYWALL: ratelimit.New(1, unpaywall.MinDelay),
+	SVC_OABUTTON:  ratelimit.New(2, time.Second),
+	SVC_DOI:       ratelimit.New(10, time.Second),
+}
+
+// serviceClient returns the http.Client for the service's API. Requests are
+// rate-limited, time out after --timeout, and, if caching is enabled, are
+// cached. Cached responses don't count against the limit.
+func serviceClient(service string) *http.Client {
+	var t http.RoundTripper = http.DefaultTransport
+	if l := serviceLimiters[service]; l != nil {
+		t = &ratelimit.Transport{Limiter: l, Transport: t}
+	}
+	if ct := cacheTransport(service, t); ct != nil {
+		t = ct
+	}
+	return &http.Client{Transport: t, Timeout: rootFlags.timeout}
+}
+
+// doiClient resolves DOIs and looks up registration agencies. Results are
+// cached for the run.
+var doiClient = doi.DefaultClient
+
+// crossrefClient is used for all CrossRef requests so that they share rate
+// limits and cached citations.
+var crossrefClient = crossref.DefaultClient
+
+// dataciteClient is used for DataCite metadata requests
+var dataciteClient = datacite.DefaultClient
+
+// initClients replaces the shared clients with ones using the configured
+// endpoints and cache.
+func initClients() {
+	doiClient = doi.NewClient(doi.Options{
+		BaseURL:    oats.DOI.URL,
+		HTTPClient: serviceClient(SVC_DOI),
+	})
+	crossrefClient = crossref.NewClient(crossref.Options{
+		BaseURL:     oats.CrossRef.URL,
+		ResolverURL: oats.DOI.URL,
+		Mailto:      oats.CrossRef.Mailto,
+		HTTPClient:  serviceClient(SVC_CROSSREF),
+	})
+	dataciteClient = datacite.NewClient(datacite.Options{
+		BaseURL:    oats.DataCite.URL,
+		HTTPClient: &http.Client{Timeout: rootFlags.timeout},
+	})
+}
+
+// newRMDClient returns an RMD client for the endpoint
+func newRMDClient(baseURL string) *rmd.Client {
+	return rmd.NewClient(rmd.Options{
+		BaseURL:    baseURL,
+		Key:        oats.RMDB.APIKey,
+		HTTPClient: serviceClient(SVC_RMD),
+	})
+}
+
+// newScholarClient returns a ScholarSphere client for the endpoint. Because
+// file uploads may be slow, requests aren't limited by --timeout; instead,
+// --timeout limits the wait for a response after each request is sent.
+func newScholarClient(baseURL string) *scholargo.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = rootFlags.timeout
+	return scholargo.NewClient(scholargo.Options{
+		BaseURL:    baseURL,
+		Key:        oats.ScholarSphere.APIKey,
+		HTTPClient: &http.Client{Transport: t},
+	})
+}
+
+// newOABClient returns an Open Access Button client
+func newOABClient() *oabutton.Client {
+	return oabutton.NewClient(oabutton.Options{
+		BaseURL:    oats.OpenAccessButton.URL,
+		Key:        oats.OpenAccessButton.Key,
+		HTTPClient: serviceClient(SVC_OABUTTON),
+	})
+}
+
+// newUnpaywallClient returns an Unpaywall client
+func newUnpaywallClient() *unpaywall.Client {
+	return unpaywall.NewClient(unpaywall.Options{
+		BaseURL:    oats.Unpaywall.URL,
+		Email:      oats.Unpaywall.Email,
+		HTTPClient: serviceClient(SVC_UNPAYWALL),
+	})
+}
@@ -7,9 +7,18 @@ package cmd
 // these checks. The file matching the 'POST_FILE_1_DOC' value in the most
 // recent Activity Insight export is used for the deposit. The file search
 // is scoped to the directory set with the 'article_path' configuration.
-// Deposit metadata is based on data in RMD, CrossRef, and the Task table.
+// Deposit metadata is based on data in RMD, the DOI's registration agency
+// (CrossRef, DataCite, etc.), and the Task table. Optional fields (keywords,
+// subjects, language, contributors, and related URLs) are filled from the
+// DOI metadata and RMD following the deposit.metadata rules in the config
+// file. Retracted works are not deposited; corrections and expressions of
+// concern are logged. Either way, the status is saved in Update_Status. After
+// the deposit, the Task's Status, ScholarSphere_Link, RMD_Updated, and
+// Deposit_Date are set. With --outbox or --send, the depositor is notified of
+// the deposit (see depositnotice.go).
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,9 +28,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/muesli/coral"
 	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/rmd"
 	"github.com/psu-libraries/oats/scholargo"
 )
@@ -43,7 +54,28 @@ default, the Task must have 'Status'='To Deposit' and
 these checks. The file matching the 'POST_FILE_1_DOC' value in the most
 recent Activity Insight export is used for the deposit. The file search
 is scoped to the directory set with the 'article_path' configuration.
-Deposit metadata is based on data in RMD, CrossRef, and the Task table.`,
+Deposit metadata is based on data in RMD, the DOI's registration agency
+(CrossRef, DataCite, etc.), and the Task table. Optional fields (keywords,
+subjects, language, contributors, and related URLs) are filled from the
+DOI metadata and RMD following the deposit.metadata rules in the config
+file. If the Task has no License, a Creative Commons license for the
+accepted manuscript in the DOI metadata is used, if there is one; otherwise
+the deposit is "In Copyright". Retracted works are not deposited; corrections and expressions of
+concern are logged. Either way, the status is saved in Update_Status.
+
+With --outbox or --send, the depositor is notified of the deposit, and the
+staff mailbox set with email.staff in the config file is copied. The message
+includes the ScholarSphere link, license, and embargo, and is rendered from
+the "deposit_notice" template (email.templates in the config file).
+Messages are saved as .eml files in the --outbox directory or sent with the
+configured SMTP server (--send). Notices are only sent after the Task is
+updated, and --send requires production mode (-p). Template data:
+  .User, .Name, .Email      depositor
+  .ActivityInsightID, .Title, .Journal, .DOI
+  .Link                     ScholarSphere link
+  .License, .LicenseURL     license in Airtable and the license applied
+  .Embargo                  embargo end date
+  .Contact                  address for corrections`,
 	RunE: runDeposit,
 	Args: coral.MinimumNArgs(1),
 }
@@ -54,15 +86,42 @@ func init() {
 	depositCmd.Flags().BoolVarP(&depositFlags.skipStatus, "no-status", "", false, "skip check: Status='To Deposit'")
 	depositCmd.Flags().BoolVarP(&depositFlags.skipPerm, "no-permissions", "", false, "skip check: Permissions='Accepted Version OK'")
 	depositCmd.Flags().BoolVarP(&depositFlags.skipRMD, "skip-rmd", "", false, "don't do RMD update")
+	addMailFlags(depositCmd)
 }
 
+// deposit outcome
+const OUTCOME_DEPOSITED = "deposited"
+
 func runDeposit(cmd *coral.Command, args []string) error {
 	if len(args) == 0 {
 		return errors.New("expected deposit id")
 	}
-
 	// Activity Insight ID for Task
 	depositID := args[0]
+	sum := newSummary(cmd.Name(), 1)
+	res := result(depositID, "", OUTCOME_DEPOSITED)
+	// failures are reported as a failed record (exit code 2)
+	if err := depositTask(cmd.Context(), depositID, &res); err != nil {
+		log.Println(err)
+		res = res.failed(ERR_INPUT, err)
+	}
+	sum.add(res)
+	return sum.finish(nil)
+}
+
+// depositTask deposits the Task with the Activity Insight ID. res is updated
+// with the Task's record ID, DOI, and changed fields.
+func depositTask(ctx context.Context, depositID string, res *recordResult) error {
+	// sources for optional metadata fields
+	metaRules, err := metaSources(oats.Deposit.Metadata)
+	if err != nil {
+		return err
+	}
+	// nil unless --outbox or --send is set
+	notifier, err := newDepositNotifier()
+	if err != nil {
+		return err
+	}
 
 	// api endpoints
 	scholURL := oats.Config.ScholarSphere.Test
@@ -74,25 +133,22 @@ func runDeposit(cmd *coral.Command, args []string) error {
 	log.Printf("using airtable=%s, scholarsphere=%s, rmd=%s", oats.AirtableBase(), scholURL, rmdbURL)
 
 	//RMDB client
-	rmdbCli := rmd.NewClient(rmdbURL, oats.RMDB.APIKey)
+	rmdbCli := newRMDClient(rmdbURL)
 	var rmdPubs []rmd.Publication // RMD publications with depositID
 
 	// ScholarSphere Client
-	schol := scholargo.Client{
-		BaseURL: scholURL,
-		Key:     oats.Config.ScholarSphere.APIKey,
-	}
+	schol := newScholarClient(scholURL)
 	// big list of DOIS in ScholarSphere - used to check existing deposit
-	scholDOIs, err := schol.DOIs()
+	scholDOIs, err := schol.DOIs(ctx)
 	if err != nil {
-		return fmt.Errorf(`❌ failed to get current DOIs from ScholarSphere: %w`, err)
+		return withCategory(ERR_API, fmt.Errorf(`❌ failed to get current DOIs from ScholarSphere: %w`, err))
 	}
 
 	// Get Activity Insight and Task records from Airtable
 	filter := fmt.Sprintf("{%s} = '%s'", COL_ID, depositID)
-	aiRecs, err := oats.GetRecordsFilterFields(oats.Airtable.ActivityInsight, filter, nil)
+	aiRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, filter, nil)
 	if err != nil {
-		return fmt.Errorf(`❌ failed to get Airtable records: %w`, err)
+		return withCategory(ERR_AIRTABLE, fmt.Errorf(`❌ failed to get Airtable records: %w`, err))
 	}
 	if l := len(aiRecs); l != 1 {
 		return fmt.Errorf(`❌ %s: expected exactly 1 Activity Insight record, found %d`, depositID, l)
@@ -102,10 +158,11 @@ func runDeposit(cmd *coral.Command, args []string) error {
 	if l := len(intTaskIDs); !ok || l != 1 {
 		return fmt.Errorf(`❌ %s: expected 1 Task record, found %d`, depositID, l)
 	}
-	taskRec, err := oats.GetRecord(oats.Airtable.Tasks, intTaskIDs[0].(string))
+	taskRec, err := oats.GetRecord(ctx, oats.Airtable.Tasks, intTaskIDs[0].(string))
 	if err != nil {
-		return fmt.Errorf("❌ %s: %w", depositID, err)
+		return withCategory(ERR_AIRTABLE, fmt.Errorf("❌ %s: %w", depositID, err))
 	}
+	res.ID = taskRec.ID
 
 	// check that deposit is appropriate
 	if !depositFlags.skipStatus {
@@ -167,116 +224,135 @@ func runDeposit(cmd *coral.Command, args []string) error {
 	meta.Title, _ = taskRec.Fields[COL_TITLE].(string)
 	meta.Description, _ = taskRec.Fields[COL_ABSTRACT].(string)
 	meta.PublishedDate, _ = taskRec.Fields[COL_PUBDATE].(string)
+	airPubDate := meta.PublishedDate
 	meta.Embargo, _ = taskRec.Fields[COL_EMBARGO].(string)
 	meta.PublisherStatement, _ = taskRec.Fields[COL_STMNT].(string)
+	// without a license in Airtable, rights may come from CrossRef (see
+	// enrichMeta)
 	airLicense, _ := taskRec.Fields[COL_LICENSE].(string)
-	meta.Rights = convertLicense(airLicense)
+	if airLicense != "" {
+		meta.Rights = convertLicense(airLicense)
+	}
 
 	// get doi - try Airtable and RMD
-	doi, _ := taskRec.Fields[COL_DOI].(string)
-	doi = cleanDOI(doi)
-	if doi == "" {
-		rmdPubs, err = rmdbCli.PublicationsAI(depositID)
+	airDOI, _ := taskRec.Fields[COL_DOI].(string)
+	workDOI, _ := doi.Parse(airDOI)
+	if workDOI == "" {
+		rmdPubs, err = rmdbCli.PublicationsAI(ctx, depositID)
 		if err != nil {
-			return fmt.Errorf("❌ %s: task cannot be deposited: %w", depositID, err)
-		}
-		if doi = findPubDOI(rmdPubs); doi != "" {
-			doi = cleanDOI(doi)
+			return withCategory(ERR_API, fmt.Errorf("❌ %s: task cannot be deposited: %w", depositID, err))
 		}
+		workDOI = findPubDOI(rmdPubs)
 	}
+	res.DOI = workDOI.String()
 
-	if doi != "" {
+	var (
+		citation     *crossref.Citation
+		crossAuthors []crossref.Author
+	)
+	if workDOI != "" {
 		// Additional check if we have a DOI
-		for d, recs := range scholDOIs {
-			if strings.EqualFold("doi:"+doi, d) && len(recs) > 0 {
-				return fmt.Errorf("❌ %s: already deposited: %s (%s)", depositID, doi, recs[0])
-			}
+		if recs := scholDOIs.Find(workDOI); len(recs) > 0 {
+			return fmt.Errorf("❌ %s: already deposited: %s (%s)", depositID, workDOI, recs[0])
 		}
-		// use CrossRef metadata if available
-		citation, err := crossref.GetCitation(doi)
+		// use metadata from the DOI's registration agency if available
+		citation, err = getCitation(ctx, workDOI)
 		if err != nil {
-			return fmt.Errorf("❌ %s: task cannot be deposited: %w", depositID, err)
+			return withCategory(ERR_API, fmt.Errorf("❌ %s: task cannot be deposited: %w", depositID, err))
 		}
-		if meta.PublishedDate == "" && len(citation.Issued.DateParts) > 0 {
-			meta.PublishedDate, _ = convertDate(citation.Issued.DateParts[0])
+		if err := recordUpdateStatus(taskRec, citation); err != nil {
+			return withCategory(ERR_AIRTABLE, fmt.Errorf("❌ %s: %w", depositID, err))
 		}
-		meta.Identifier = []string{doi}
+		if citation.Retracted() {
+			return fmt.Errorf("❌ %s: task cannot be deposited: %s", depositID, updateStatusValue(citation))
+		}
+		if meta.PublishedDate == "" {
+			meta.PublishedDate = citation.PublicationDate().EDTF()
+		}
+		meta.Identifier = []string{workDOI.String()}
 		if meta.Description == "" {
-			meta.Description = citation.Abstract
+			meta.Description = citation.PlainAbstract()
 		}
-		meta.Creators = convertCrossRefAuthors(citation.Author)
+		crossAuthors = citation.Author
 		if citation.Publisher != "" {
 			meta.Publisher = []string{citation.Publisher}
 		}
 		meta.Source = citation.ContainerTitle
 	}
 
-	// if we have any missing values, try RMD as a last resort
-	if meta.Description == "" || meta.PublishedDate == "" || len(meta.Creators) == 0 {
-		if rmdPubs == nil {
-			rmdPubs, err = rmdbCli.PublicationsAI(depositID)
-			if err != nil {
-				return fmt.Errorf("❌ %s: failed to connect to rmdb: %w", depositID, err)
+	// RMD is used for missing values and for linking creators to PSU IDs
+	rmdRequired := meta.Description == "" || meta.PublishedDate == "" || len(crossAuthors) == 0
+	if rmdPubs == nil {
+		rmdPubs, err = rmdbCli.PublicationsAI(ctx, depositID)
+		if err != nil {
+			if rmdRequired {
+				return withCategory(ERR_API, fmt.Errorf("❌ %s: failed to connect to rmdb: %w", depositID, err))
 			}
+			log.Printf("⚠️ %s: failed to connect to rmdb, creators will not have PSU IDs: %s", depositID, err)
 		}
-		for _, p := range rmdPubs {
-			if strings.HasSuffix(strings.ToLower(p.Attributes.DOI), strings.ToLower(doi)) {
-				if meta.Description == "" {
-					meta.Description = p.Attributes.Abstract
-				}
-				if meta.PublishedDate == "" {
-					meta.PublishedDate = p.Attributes.PublishedOn
-				}
-				if len(meta.Creators) == 0 {
-					meta.Creators = convertRMDAuthors(p.Attributes.Contributors)
-				}
-				if len(meta.Source) == 0 {
-					meta.Source = []string{p.Attributes.JournalTitle}
-				}
-				if len(meta.Publisher) == 0 {
-					meta.Publisher = []string{p.Attributes.Publisher}
-				}
-				break
+	}
+	var rmdPub *rmd.Publication
+	for i, p := range rmdPubs {
+		if pubDOI, _ := doi.Parse(p.Attributes.DOI); workDOI == "" || pubDOI == workDOI {
+			rmdPub = &rmdPubs[i]
+			if meta.Description == "" {
+				meta.Description = p.Attributes.Abstract
 			}
+			if meta.PublishedDate == "" {
+				meta.PublishedDate = p.Attributes.PublishedOn
+			}
+			if len(meta.Source) == 0 {
+				meta.Source = []string{p.Attributes.JournalTitle}
+			}
+			if len(meta.Publisher) == 0 {
+				meta.Publisher = []string{p.Attributes.Publisher}
+			}
+			break
 		}
 	}
-
-	// check all required values are present
-	if meta.Title == "" {
-		return fmt.Errorf("❌ %s: task cannot be deposited: not title for %s. Try setting in Airtable", depositID, doi)
-	}
-	if meta.Description == "" {
-		return fmt.Errorf("❌ %s: task cannot be deposited: no abstract for %s. Try setting in Airtable", depositID, doi)
+	var rmdContribs []rmd.Contributor
+	if rmdPub != nil {
+		rmdContribs = rmdPub.Attributes.Contributors
 	}
-	if meta.PublishedDate == "" {
-		return fmt.Errorf("❌ %s: task cannot be deposited: no publication date for %s. Try setting in Airtable.", depositID, doi)
+	var unmatched []rmd.Contributor
+	meta.Creators, unmatched = reconcileAuthors(crossAuthors, rmdContribs)
+	for _, c := range unmatched {
+		log.Printf("⚠️ %s: PSU contributor in RMD not found in CrossRef authors: %s", depositID, contributorName(c))
 	}
-	if meta.Rights == "" {
-		return fmt.Errorf("❌ %s: task cannot be deposited: unknown license %s", depositID, airLicense)
+
+	// keywords, subjects, etc.
+	enrichMeta(meta, citation, rmdPub, metaRules)
+	if airLicense == "" && meta.Rights == "" {
+		meta.Rights = convertLicense("")
 	}
-	if len(meta.Creators) == 0 {
-		return fmt.Errorf("❌ %s: task cannot be deposited: missing creators", depositID)
+
+	// check metadata before uploading anything
+	if err := meta.Validate(); err != nil {
+		if meta.Rights == "" {
+			return fmt.Errorf("❌ %s: task cannot be deposited: unknown license %s", depositID, airLicense)
+		}
+		return fmt.Errorf("❌ %s: task cannot be deposited (doi=%s): %w. Try setting missing values in Airtable", depositID, workDOI, err)
 	}
 
 	// do deposit
-	resp, err := schol.Deposit(meta, depositor, depositFlags.filePath)
+	resp, err := schol.Deposit(ctx, meta, depositor, depositFlags.filePath)
 	if err != nil {
 		log.Println("------ JSON Dump -----------")
 		defer log.Println("---------------------")
-		enc := json.NewEncoder(os.Stdout)
+		enc := json.NewEncoder(os.Stderr)
 		enc.SetIndent(``, `  `)
 		enc.Encode(meta)
-		return fmt.Errorf("❌ %s: deposit failed: %w", depositID, err)
+		return withCategory(ERR_API, fmt.Errorf("❌ %s: deposit failed: %w", depositID, err))
 	}
 	scholLink := scholURL + resp.URL
-	log.Printf("✅ %s: deposited file=%s, doi=%s\n", depositID, depositFlags.filePath, doi)
+	log.Printf("✅ %s: deposited file=%s, doi=%s\n", depositID, depositFlags.filePath, workDOI)
 	if err != nil {
 		return fmt.Errorf("❌ %s: failed to update Airtable with deposit information: %w", depositID, err)
 	}
 	var rmdUpdated bool
 	if !depositFlags.skipRMD {
 		//update RMDB with scholarsphere links
-		err = rmdbCli.UpdateScholarSphereLink(depositID, scholLink)
+		err = rmdbCli.UpdateScholarSphereLink(ctx, depositID, scholLink)
 		if err != nil {
 			log.Printf("❌ %s: failed to update RMDB: %s", depositID, err)
 		} else {
@@ -290,9 +366,39 @@ func runDeposit(cmd *coral.Command, args []string) error {
 		"Status":             "Deposited",
 		"ScholarSphere_Link": scholLink,
 		"RMD_Updated":        rmdUpdated,
+		COL_DEP_DATE:         time.Now().Format("2006-01-02"),
+	}
+	if airPubDate == "" {
+		updates[COL_PUBDATE] = meta.PublishedDate
+	}
+	if _, err = taskRec.UpdateRecordPartial(updates); err != nil {
+		return withCategory(ERR_AIRTABLE, err)
+	}
+	if notifier != nil {
+		first, _ := aiRec.Fields["First Name"].(string)
+		last, _ := aiRec.Fields["Last Name"].(string)
+		notice := &depositNotice{
+			User:              depositor,
+			Name:              strings.TrimSpace(first + " " + last),
+			Email:             userEmail(depositor),
+			ActivityInsightID: depositID,
+			Title:             meta.Title,
+			DOI:               workDOI,
+			Link:              scholLink,
+			License:           airLicense,
+			LicenseURL:        meta.Rights,
+			Embargo:           meta.Embargo,
+		}
+		notice.Journal, _ = taskRec.Fields[COL_JOURNAL].(string)
+		// the deposit is done, so failed notices are only logged
+		if dest, err := notifier.notify(ctx, notice); err != nil {
+			log.Printf("❌ %s: failed to notify depositor: %s", depositID, err)
+		} else {
+			log.Printf("✅ %s: depositor notified: %s", depositID, dest)
+		}
 	}
-	_, err = taskRec.UpdateRecordPartial(updates)
-	return err
+	*res = res.changed(updates)
+	return nil
 }
 
 func findFile(base string, name string) (string, error) {
@@ -341,12 +447,19 @@ func convertCrossRefAuthors(auths []crossref.Author) []scholargo.Creator {
 		if a.Family == "" && a.Name == "" {
 			continue
 		}
-		auth := scholargo.Creator{Orcid: a.ORCID}
+		var auth scholargo.Creator
 		if a.Name == "" {
 			auth.Name = a.Given + " " + a.Family
 		} else {
 			auth.Name = a.Name
 		}
+		if a.ORCID != "" {
+			orcid, err := scholargo.ParseORCID(a.ORCID)
+			if err != nil {
+				log.Printf("⚠️ dropping ORCID for %s: %s", auth.Name, err)
+			}
+			auth.Orcid = orcid
+		}
 		ret = append(ret, auth)
 	}
 	return ret
@@ -362,19 +475,3 @@ func convertRMDAuthors(auths []rmd.Contributor) []scholargo.Creator {
 	}
 	return ret
 }
-
-// convert [y,m,d] to "y-m-d"
-func convertDate(issue [3]int) (string, error) {
-	if issue[0] == 0 {
-		return "", errors.New("empty date")
-	}
-	date := fmt.Sprintf("%d", issue[0])
-
-	if issue[1] != 0 {
-		date += fmt.Sprintf("-%02d", issue[1])
-		if issue[2] != 0 {
-			date += fmt.Sprintf("-%02d", issue[2])
-		}
-	}
-	return date, nil
-}
@@ -0,0 +1,120 @@
+package cmd
+
+// With --outbox or --send, the deposit command notifies the depositor of a
+// successful deposit once the Task is updated, copying the staff mailbox set
+// with email.staff in the config file. The message is rendered from the
+// "deposit_notice" template (see mail.go) and includes the ScholarSphere link,
+// the license and embargo applied, and how to request corrections.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"text/template"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+// name of the template in the email.templates config
+const TMPL_DEPOSIT_NOTICE = "deposit_notice"
+
+// depositNotice is the template data for a deposit notice
+type depositNotice struct {
+	User              string
+	Name              string // name from Activity Insight
+	Email             string // recipient address
+	ActivityInsightID string
+	Title             string
+	Journal           string
+	DOI               doi.DOI
+	Link              string // ScholarSphere link
+	License           string // license in Airtable
+	LicenseURL        string // license applied to the deposit
+	Embargo           string // embargo end date
+	Contact           string // address for corrections
+}
+
+// depositNotifier sends deposit notices
+type depositNotifier struct {
+	mailer *mailer
+	tmpl   *template.Template
+	staff  string // address copied on notices
+}
+
+// newDepositNotifier returns a depositNotifier if --outbox or --send is set.
+// It returns nil if neither is set. --send requires production mode: test
+// deposits go to ScholarSphere QA, and faculty shouldn't get notices for them.
+func newDepositNotifier() (*depositNotifier, error) {
+	if mailFlags.outbox == "" && !mailFlags.send {
+		return nil, nil
+	}
+	if mailFlags.send && !oats.Production {
+		return nil, errors.New("--send requires production mode (-p); use --outbox for test deposits")
+	}
+	m, err := newMailer()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := loadMailTemplate(TMPL_DEPOSIT_NOTICE, defaultDepositNoticeTmpl)
+	if err != nil {
+		return nil, err
+	}
+	n := &depositNotifier{mailer: m, tmpl: tmpl}
+	if oats.Email.Staff != "" {
+		staff, err := mail.ParseAddress(oats.Email.Staff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email.staff: %w", err)
+		}
+		n.staff = staff.Address
+	}
+	return n, nil
+}
+
+// notify sends the notice or saves it to the outbox. It returns the outbox
+// file or the recipients.
+func (n *depositNotifier) notify(ctx context.Context, notice *depositNotice) (string, error) {
+	if notice.Contact == "" {
+		notice.Contact = n.mailer.from.Address
+		if n.mailer.replyTo != nil {
+			notice.Contact = n.mailer.replyTo.Address
+		}
+	}
+	msg, err := renderMessage(n.tmpl, notice)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", TMPL_DEPOSIT_NOTICE, err)
+	}
+	msg.To = []string{notice.Email}
+	if n.staff != "" {
+		msg.Cc = []string{n.staff}
+	}
+	return n.mailer.deliver(ctx, notice.ActivityInsightID+"-deposit", msg)
+}
+
+// defaultDepositNoticeTmpl is the built-in deposit_notice template
+var defaultDepositNoticeTmpl = `{{define "subject"}}Your article is now in ScholarSphere: {{.Title}}{{end}}
+Dear {{if .Name}}{{.Name}}{{else}}colleague{{end}},
+
+We've deposited your article in ScholarSphere, Penn State's open access
+repository:
+
+  {{.Title}}
+{{- if .Journal}}
+  {{.Journal}}{{end}}
+{{- if .DOI}}
+  https://doi.org/{{.DOI}}{{end}}
+
+  ScholarSphere: {{.Link}}
+  License: {{if .License}}{{.License}} ({{.LicenseURL}}){{else}}{{.LicenseURL}}{{end}}
+{{- if .Embargo}}
+  Embargo: the file will be public after {{.Embargo}}{{end}}
+
+The deposit uses the accepted manuscript you shared with Activity
+Insight and follows the publisher's open access policy. If anything
+should be corrected, such as the title, authors, or file, or if the
+article shouldn't have been deposited, please reply to this message or
+write to {{.Contact}} and we'll update it.
+
+Thank you,
+Open Access Team
+`
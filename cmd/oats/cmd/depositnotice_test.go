@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDepositNotice(t *testing.T) {
+	defer setTestOats()()
+	prevFlags := mailFlags
+	defer func() { mailFlags = prevFlags }()
+
+	// no notices without --outbox or --send
+	n, err := newDepositNotifier()
+	if err != nil || n != nil {
+		t.Fatalf("expected nil notifier, got %v, %v", n, err)
+	}
+
+	oats.Email.From = "OA Team <oa@example.com>"
+	oats.Email.SMTP.Host = "localhost:2525"
+
+	// --send only in production
+	mailFlags.send = true
+	if _, err := newDepositNotifier(); err == nil {
+		t.Error("expected error for --send without production mode")
+	}
+	oats.Production = true
+	if _, err := newDepositNotifier(); err != nil {
+		t.Errorf("unexpected error for --send in production mode: %s", err)
+	}
+	mailFlags.send = false
+	oats.Production = false
+
+	outbox := t.TempDir()
+	mailFlags.outbox = outbox
+	oats.Email.ReplyTo = "help@example.com"
+	oats.Email.Staff = "deposits@example.com"
+	n, err = newDepositNotifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := n.notify(context.Background(), &depositNotice{
+		User:              "axl1",
+		Name:              "Ada Lovelace",
+		Email:             "axl1@psu.edu",
+		ActivityInsightID: "123",
+		Title:             "On Engines",
+		DOI:               "10.1000/1",
+		Link:              "https://scholarsphere.psu.edu/resources/abc",
+		License:           "cc-by",
+		LicenseURL:        "https://creativecommons.org/licenses/by/4.0/",
+		Embargo:           "2023-01-01",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != filepath.Join(outbox, "123-deposit.eml") {
+		t.Errorf("unexpected file: %s", file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("To") != "axl1@psu.edu" || msg.Header.Get("Cc") != "deposits@example.com" {
+		t.Errorf("unexpected recipients: %v", msg.Header)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "Your article is now in ScholarSphere: On Engines" {
+		t.Errorf("unexpected subject: %q", subject)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Dear Ada Lovelace,",
+		"ScholarSphere: https://scholarsphere.psu.edu/resources/abc",
+		"License: cc-by (https://creativecommons.org/licenses/by/4.0/)",
+		"Embargo: the file will be public after 2023-01-01",
+		"write to help@example.com",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in body:\n%s", want, body)
+		}
+	}
+}
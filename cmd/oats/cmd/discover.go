@@ -0,0 +1,100 @@
+package cmd
+
+// DOI discovery: for Tasks without a DOI in Airtable or RMD, the dois command
+// can search CrossRef using the title (TITLE), journal (JOURNAL_NAME), author,
+// and year from Activity Insight. The best candidate is confirmed if its score
+// clears a threshold; otherwise it is saved as a suggestion.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
+)
+
+// candidates scoring below this are not suggested
+const minSuggestScore = 0.5
+
+// discoveryWork returns the work with the title and journal from the
+// Activity Insight record, which are used for searching CrossRef. The Task's
+// title and journal are used if the record doesn't have them.
+func discoveryWork(work workInfo, ai *airtable.Record) workInfo {
+	if ai == nil {
+		return work
+	}
+	if title, _ := ai.Fields[AI_TITLE].(string); strings.TrimSpace(title) != "" {
+		work.Title = strings.TrimSpace(title)
+	}
+	if journal, _ := ai.Fields[AI_JOURNAL].(string); strings.TrimSpace(journal) != "" {
+		work.Journal = strings.TrimSpace(journal)
+	}
+	return work
+}
+
+// discoverDOI searches CrossRef for the work and returns the best matching
+// candidate. It returns nil if there are no candidates.
+func discoverDOI(ctx context.Context, work workInfo) (*crossref.Citation, workMatch, error) {
+	if work.Title == "" {
+		return nil, workMatch{}, fmt.Errorf("cannot search without a title")
+	}
+	q := crossref.Query{
+		Bibliographic: strings.TrimSpace(work.Title + " " + work.Journal),
+		Author:        work.LastName,
+	}
+	if work.Year > 0 {
+		q.FromYear, q.UntilYear = work.Year-1, work.Year+1
+	}
+	cands, err := crossrefClient.Search(ctx, q)
+	if err != nil {
+		return nil, workMatch{}, fmt.Errorf("CrossRef search failed: %w", err)
+	}
+	var (
+		best      *crossref.Citation
+		bestMatch workMatch
+	)
+	for i := range cands {
+		if m := matchWork(work, &cands[i]); best == nil || m.Score > bestMatch.Score {
+			best, bestMatch = &cands[i], m
+		}
+	}
+	return best, bestMatch, nil
+}
+
+// updateSuggestDOI saves a discovered DOI that didn't clear the threshold
+func updateSuggestDOI(r *airtable.Record, d doi.DOI, score float64) (recordResult, error) {
+	update := map[string]interface{}{
+		COL_DOI_SUGGEST: fmt.Sprintf("%s (score=%.2f)", d, score),
+	}
+	if _, err := r.UpdateRecordPartial(update); err != nil {
+		err = fmt.Errorf("failed to save DOI suggestion: %w", err)
+		return result(r.ID, d, "").failed(ERR_AIRTABLE, err), err
+	}
+	log.Printf("❓ suggested: %s (score=%.2f)", d, score)
+	return result(r.ID, d, OUTCOME_SUGGESTED).changed(update), nil
+}
+
+// runDiscovery tries to find the DOI for the task in CrossRef
+func runDiscovery(ctx context.Context, r *airtable.Record, work workInfo, threshold float64) (recordResult, error) {
+	cite, match, err := discoverDOI(ctx, work)
+	if err != nil {
+		log.Printf("❌ %s: %s", work.Title, err)
+		return result(r.ID, "", "").failed(ERR_API, err), nil
+	}
+	if cite == nil || match.Score < minSuggestScore {
+		log.Printf("❌ no DOI found in CrossRef for: %s", work.Title)
+		return result(r.ID, "", OUTCOME_NOT_FOUND), nil
+	}
+	d, err := doi.Parse(cite.DOI)
+	if err != nil {
+		log.Printf("❌ %s: CrossRef returned invalid DOI: %s", work.Title, err)
+		return result(r.ID, "", "").failed(ERR_API, err), nil
+	}
+	if match.Score < threshold {
+		return updateSuggestDOI(r, d, match.Score)
+	}
+	return updateConfirmDOI(r, d, cite, match)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/mockserver"
+)
+
+func TestDiscoveryWork(t *testing.T) {
+	work := workInfo{Title: "Task Title", Journal: "Task Journal", LastName: "Ullrich"}
+	table := []struct {
+		desc   string
+		ai     *airtable.Record
+		expect workInfo
+	}{
+		{desc: "no record", expect: work},
+		{
+			desc:   "from Activity Insight",
+			ai:     &airtable.Record{Fields: map[string]interface{}{AI_TITLE: " AI Title ", AI_JOURNAL: "AI Journal"}},
+			expect: workInfo{Title: "AI Title", Journal: "AI Journal", LastName: "Ullrich"},
+		},
+		{
+			desc:   "blank in Activity Insight",
+			ai:     &airtable.Record{Fields: map[string]interface{}{AI_TITLE: " ", AI_JOURNAL: "AI Journal"}},
+			expect: workInfo{Title: "Task Title", Journal: "AI Journal", LastName: "Ullrich"},
+		},
+	}
+	for _, row := range table {
+		if got := discoveryWork(work, row.ai); got != row.expect {
+			t.Errorf("%s: expected %+v, got %+v", row.desc, row.expect, got)
+		}
+	}
+}
+
+func TestRunDiscovery(t *testing.T) {
+	ctx := context.Background()
+	task := func(id string) mockserver.Record {
+		return mockserver.Record{ID: id, Fields: map[string]interface{}{COL_TITLE: id}}
+	}
+	srv := newMockServices(t, &mockserver.Fixtures{
+		Airtable: map[string]map[string][]mockserver.Record{mockBase: {
+			"Tasks": {task("recConfirm"), task("recSuggest"), task("recWeak"), task("recNone"), task("recNoTitle")},
+		}},
+		CrossRef: []json.RawMessage{
+			json.RawMessage(`{"DOI": "10.1000/giant", "title": ["Evolution of the giant planet cores"],
+				"container-title": ["Monthly Notices of the Royal Astronomical Society"],
+				"author": [{"given": "Alexander", "family": "Ullrich"}], "volume": "498", "issue": "2",
+				"published-online": {"date-parts": [[2020, 8, 5]]}}`),
+			json.RawMessage(`{"DOI": "10.1000/tidal", "title": ["Tidal heating in icy satellites"],
+				"container-title": ["Icarus"], "author": [{"given": "Ana", "family": "Costa"}],
+				"published-online": {"date-parts": [[2019, 1, 5]]}}`),
+		},
+	})
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]*airtable.Record)
+	for _, r := range recs {
+		byID[r.ID] = r
+	}
+	table := []struct {
+		id       string
+		work     workInfo
+		action   string
+		doi      string
+		category string
+	}{
+		{
+			id: "recConfirm", action: OUTCOME_CONFIRMED, doi: "10.1000/giant",
+			work: workInfo{Title: "Evolution of the Giant Planet Cores", Journal: "Monthly Notices of the Royal Astronomical Society",
+				LastName: "Ullrich", Year: 2020, Volume: "498", Issue: "2"},
+		},
+		{
+			// title differs, other signals match
+			id: "recSuggest", action: OUTCOME_SUGGESTED, doi: "10.1000/tidal",
+			work: workInfo{Title: "Tidal heating of icy moons", Journal: "Icarus", LastName: "Costa", Year: 2019},
+		},
+		{
+			// best candidate scores below minSuggestScore
+			id: "recWeak", action: OUTCOME_NOT_FOUND,
+			work: workInfo{Title: "Planet formation", LastName: "Nobody", Year: 2005},
+		},
+		{
+			// no candidates
+			id: "recNone", action: OUTCOME_NOT_FOUND,
+			work: workInfo{Title: "Lattice chromodynamics"},
+		},
+		{
+			id: "recNoTitle", action: OUTCOME_FAILED, category: ERR_API,
+			work: workInfo{LastName: "Ullrich"},
+		},
+	}
+	for _, row := range table {
+		res, err := runDiscovery(ctx, byID[row.id], row.work, 0.85)
+		if err != nil {
+			t.Fatalf("%s: %s", row.id, err)
+		}
+		if res.Action != row.action || res.DOI != row.doi || res.Category != row.category {
+			t.Errorf("%s: expected %s (doi=%q, category=%q), got %+v", row.id, row.action, row.doi, row.category, res)
+		}
+	}
+
+	fields := make(map[string]map[string]interface{})
+	for _, r := range srv.Records(mockBase, "Tasks") {
+		fields[r.ID] = r.Fields
+	}
+	if f := fields["recConfirm"]; f[COL_DOI] != "10.1000/giant" || f[COL_DOI_CONF] != true || f[COL_PUBDATE] != "2020-08-05" {
+		t.Errorf("unexpected confirmed task: %v", f)
+	}
+	if f := fields["recSuggest"]; f[COL_DOI_SUGGEST] != "10.1000/tidal (score=0.77)" || f[COL_DOI] != nil {
+		t.Errorf("unexpected suggested task: %v", f)
+	}
+	for _, id := range []string{"recWeak", "recNone", "recNoTitle"} {
+		if len(fields[id]) != 1 {
+			t.Errorf("%s: expected task to be unchanged, got %v", id, fields[id])
+		}
+	}
+}
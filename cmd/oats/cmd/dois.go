@@ -1,24 +1,38 @@
 package cmd
 
 // The dois command attempts to confirm unconfirmed DOIs in Airtable using
-// information from CrossRef and RMD. Only active Tasks (Status!="Complete")
-// with unconfirmed DOIs (DOI_Confirmed=false) are affected. The confirmation
-// process is as follows: If the Task has a DOI value, query CrossRef and
-// compare titles; if the titles are similar, the DOI is confirmed. For Tasks
-// without DOI values, query RMD using the Activity Insight ID. If a DOI is
-// found and the titles in RMD and Airtable are similar, validate the DOI as
-// above.
+// information from the DOI's registration agency (CrossRef, DataCite, etc.)
+// and RMD. Only active Tasks (Status!="Complete") with unconfirmed DOIs
+// (DOI_Confirmed=false) are affected. The confirmation process is as follows:
+// If the Task has a DOI value, get its metadata and compare the title,
+// journal, publication year, volume/issue, and faculty member's last name; if
+// the match score clears --min-score, the DOI is confirmed and the score and
+// reasons are saved in DOI_Score and DOI_Match_Reason. For Tasks without DOI
+// values, query RMD using the Activity Insight ID. If a DOI is found and the
+// titles in RMD and Airtable are similar, validate the DOI as above. When a
+// DOI is confirmed, the Task's Publication_Date is set from the metadata if it
+// is empty: the earliest of the online, print, and issued dates, in EDTF form,
+// and retractions or corrections are recorded in Update_Status. With
+// --discover, Tasks without a DOI in Airtable or RMD are searched for in
+// CrossRef using the title, journal, author, and year from Activity Insight
+// (the Task's title and journal if it has none). The best match is
+// confirmed if its score clears --threshold; otherwise it is saved in the
+// DOI_Suggestion column. Mismatched candidates are added to the DOI review
+// queue, if configured (see review.go); candidates that are queued or rejected
+// are skipped.
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 
-	"github.com/hbollon/go-edlib"
 	"github.com/mehanizm/airtable"
 	"github.com/muesli/coral"
 	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/rmd"
 )
 
@@ -27,9 +41,15 @@ type TitleMatchErr struct {
 	Expected string
 	Got      string
 	Source   string
+	DOI      doi.DOI // candidate DOI, if any
+	Score    float64 // match score or title similarity
+	Reason   string  // reasons for the score, if any
 }
 
 func (e *TitleMatchErr) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("title mismatch for doi=%s (source=%s, score=%.2f: %s). Expected=%s, Got=%s", e.ID, e.Source, e.Score, e.Reason, e.Expected, e.Got)
+	}
 	return fmt.Sprintf("title mismatch for doi=%s (source=%s). Expected=%s, Got=%s", e.ID, e.Source, e.Expected, e.Got)
 }
 
@@ -37,194 +57,322 @@ func (e *TitleMatchErr) Error() string {
 // Activity Insight postprint info
 var doisCmd = &coral.Command{
 	Use:   "dois",
-	Short: "Confirms unconfirmed DOIs in Airtable using CrossRef and RMD",
+	Short: "Confirms unconfirmed DOIs in Airtable using CrossRef, DataCite, and RMD",
 	Long: `The dois command attempts to confirm unconfirmed DOIs in Airtable using
-information from CrossRef and RMD. Only active Tasks (Status!="Complete")
-with unconfirmed DOIs (DOI_Confirmed=false) are affected. The confirmation
-process is as follows: If the Task has a DOI value, query CrossRef and
-compare titles; if the titles are similar, the DOI is confirmed. For Tasks
-without DOI values, query RMD using the Activity Insight ID. If a DOI is
-found and the titles in RMD and Airtable are similar, validate the DOI as 
-above.`,
+information from the DOI's registration agency (CrossRef, DataCite, etc.)
+and RMD. Only active Tasks (Status!="Complete") with unconfirmed DOIs
+(DOI_Confirmed=false) are affected. The confirmation process is as follows:
+If the Task has a DOI value, get its metadata and compare the title,
+journal, publication year, volume/issue, and faculty member's last name; if
+the match score clears --min-score, the DOI is confirmed and the score and
+reasons are saved in DOI_Score and DOI_Match_Reason. For Tasks without DOI
+values, query RMD using the Activity Insight ID. If a DOI is found and the
+titles in RMD and Airtable are similar, validate the DOI as above. When a
+DOI is confirmed, the Task's Publication_Date is set from the metadata if it
+is empty: the earliest of the online, print, and issued dates, in EDTF form,
+and retractions or corrections are recorded in Update_Status. With
+--discover, Tasks without a DOI in Airtable or RMD are searched for in
+CrossRef using the title, journal, author, and year from Activity Insight
+(the Task's title and journal if it has none). The best match is
+confirmed if its score clears --threshold; otherwise it is saved in the
+DOI_Suggestion column. If airtable.doi_review is set in the config file,
+mismatched candidates are added to the DOI review table for "oats review
+dois"; candidates that are queued or rejected are skipped.`,
 	RunE: runDOIs,
 }
 
+var doisFlags struct {
+	discover  bool
+	threshold float64
+	minScore  float64
+}
+
+// dois command outcomes, in addition to OUTCOME_SKIPPED and OUTCOME_FAILED
+const (
+	OUTCOME_CONFIRMED  = "confirmed"
+	OUTCOME_SUGGESTED  = "suggested"
+	OUTCOME_MISMATCHED = "mismatched"
+	OUTCOME_NOT_FOUND  = "not found"
+)
+
+// errNoRMDDOI is returned by confirmRMD if RMD doesn't have a DOI for the task
+var errNoRMDDOI = errors.New("no DOI in RMD")
+
 func init() {
+	addWorkersFlag(doisCmd)
 	rootCmd.AddCommand(doisCmd)
+	doisCmd.Flags().BoolVarP(&doisFlags.discover, "discover", "", false, "search CrossRef for tasks without DOIs")
+	doisCmd.Flags().Float64VarP(&doisFlags.threshold, "threshold", "", 0.85, "minimum score for confirming discovered DOIs (0-1)")
+	doisCmd.Flags().Float64VarP(&doisFlags.minScore, "min-score", "", 0.7, "minimum score for confirming existing DOIs (0-1)")
 }
 
 // Run implements Cmd for PermissionsCmd
 func runDOIs(cmd *coral.Command, args []string) error {
+	ctx := cmd.Context()
 	// Note: always using production rmb url
 	rmdbURL := oats.RMDB.Production
-	rmdbC := rmd.NewClient(rmdbURL, oats.RMDB.APIKey)
+	rmdbC := newRMDClient(rmdbURL)
 	// map: Airtable Record ID -> Activity Insight ID
 	// Needed to get actual Activity Insight ID for Task
 	AIIDlookup := map[string]string{}
-	aiRecs, err := oats.GetRecordsFilterFields(oats.Airtable.ActivityInsight, "", []string{COL_ID})
+	// map: Airtable Record ID -> Activity Insight record (used for matching)
+	aiLookup := map[string]*airtable.Record{}
+	aiCols := []string{COL_ID, AI_LAST_NAME, AI_PUB_YEAR, AI_VOLUME, AI_ISSUE, AI_TITLE, AI_JOURNAL}
+	aiRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, "", aiCols)
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 	for _, rec := range aiRecs {
 		AIIDlookup[rec.ID], _ = rec.Fields[COL_ID].(string)
+		aiLookup[rec.ID] = rec
 	}
 	// filter unconfirmed DOIs for active Tasks
 	filter := fmt.Sprintf("AND(NOT({%s}),{%s} != \"Complete\")", COL_DOI_CONF, COL_STATUS)
-	cols := []string{COL_AI_ID, COL_DOI, COL_DOI_CONF, COL_STATUS, COL_TITLE}
-	recs, err := oats.GetRecordsFilterFields(oats.Airtable.Tasks, filter, cols)
+	cols := []string{COL_AI_ID, COL_DOI, COL_DOI_CONF, COL_STATUS, COL_TITLE, COL_PUBDATE, COL_JOURNAL}
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, cols)
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 	log.Printf("Found %d active tasks with unconfirmed DOIs in Airtable", len(recs))
-
+	reviews, err := loadDOIReviews(ctx)
+	if err != nil {
+		return err
+	}
+	// fetch CrossRef citations for Task DOIs in batches; DOIs registered
+	// elsewhere are fetched individually later
+	var taskDOIs []doi.DOI
 	for _, r := range recs {
-		doi, _ := r.Fields[COL_DOI].(string)
+		airDOI, _ := r.Fields[COL_DOI].(string)
+		if d, err := doi.Parse(airDOI); err == nil {
+			taskDOIs = append(taskDOIs, d)
+		}
+	}
+	if found, err := crossrefClient.Works(ctx, taskDOIs); err != nil {
+		log.Printf("⚠️ CrossRef batch request failed: %s", err)
+	} else {
+		log.Printf("Found %d of %d Task DOIs in CrossRef", len(found), len(taskDOIs))
+	}
+
+	// confirmTask tries to confirm the task's DOI and returns the result. An
+	// error stops the command.
+	confirmTask := func(r *airtable.Record) (recordResult, error) {
+		airDOI, _ := r.Fields[COL_DOI].(string)
 		airTitle, _ := r.Fields[COL_TITLE].(string)
-		doi = cleanDOI(doi)
+		taskDOI, _ := doi.Parse(airDOI)
+		airIDs, _ := r.Fields[COL_AI_ID].([]interface{})
+		var aiRec *airtable.Record
+		if len(airIDs) == 1 {
+			aiRec = aiLookup[airIDs[0].(string)]
+		}
+		work := newWorkInfo(r, aiRec)
 
-		if doi != "" {
-			// If DOI is present, try to confirm with CrossRef
-			err := confirmDOICrossRef(doi, airTitle)
+		if taskDOI != "" {
+			if dec, skip := reviews.skip(r.ID, taskDOI); skip {
+				log.Printf("⏸ %s: skipped, in review queue (%s)", taskDOI, reviewStatus(dec))
+				return result(r.ID, taskDOI, OUTCOME_SKIPPED), nil
+			}
+			// If DOI is present, try to confirm with its metadata
+			cite, match, err := confirmDOIMeta(ctx, taskDOI, work, doisFlags.minScore)
 			if err != nil {
 				var titleErr *TitleMatchErr
 				if errors.As(err, &titleErr) {
-					log.Printf("❌ %s: Mismatch between titles in Airtable and CrossRef", doi)
-					log.Println(" - Airtable:", airTitle)
-					log.Println(" - CrossRef:", titleErr.Got)
-				} else {
-					log.Printf("❌ %s: %s", doi, err.Error())
+					log.Print(mismatchMessage(fmt.Sprintf("%s: Mismatch between Airtable and %s (score=%.2f)",
+						taskDOI, titleErr.Source, titleErr.Score), airTitle, titleErr))
+					return queueMismatch(ctx, reviews, r, taskDOI, titleErr)
 				}
-				continue
-			}
-			if err := updateConfirmDOI(r, doi); err != nil {
-				return err
+				log.Printf("❌ %s: %s", taskDOI, err.Error())
+				return result(r.ID, taskDOI, "").failed(ERR_API, err), nil
 			}
-			continue
+			return updateConfirmDOI(r, taskDOI, cite, match)
 		}
 
 		// Try to find DOI from RMD using Activity Insight ID
 		var AIID string
-		airIDs, ok := r.Fields[COL_AI_ID].([]interface{})
-		if !ok || len(airIDs) != 1 {
-			return fmt.Errorf(`task not linked to a single Activity Insight record, title=%s`, airTitle)
+		if len(airIDs) != 1 {
+			err := fmt.Errorf(`task not linked to a single Activity Insight record, title=%s`, airTitle)
+			return result(r.ID, "", "").failed(ERR_INPUT, err), err
 		}
 		AIID = AIIDlookup[airIDs[0].(string)]
 		if AIID == "" {
-			return fmt.Errorf(`failed to find get Activity Insight ID for title=%s`, airTitle)
+			err := fmt.Errorf(`failed to find get Activity Insight ID for title=%s`, airTitle)
+			return result(r.ID, "", "").failed(ERR_INPUT, err), err
+		}
+		rmdDOI, cite, match, err := confirmRMD(ctx, rmdbC, AIID, work, doisFlags.minScore)
+		if errors.Is(err, errNoRMDDOI) && doisFlags.discover {
+			return runDiscovery(ctx, r, discoveryWork(work, aiRec), doisFlags.threshold)
 		}
-		doi, err := confirmRMD(rmdbC, AIID, airTitle)
 		if err != nil {
 			var titleErr *TitleMatchErr
 			if errors.As(err, &titleErr) {
-				log.Printf("❌ %s: Mismatch between titles in Airtable and RMD", AIID)
-				log.Println(" - Airtable:", airTitle)
-				log.Println(" - RMD:", titleErr.Got)
-			} else {
-				log.Printf("❌ %s: %s", AIID, err.Error())
+				if dec, skip := reviews.skip(r.ID, titleErr.DOI); skip {
+					log.Printf("⏸ %s: skipped, in review queue (%s)", titleErr.DOI, reviewStatus(dec))
+					return result(r.ID, titleErr.DOI, OUTCOME_SKIPPED), nil
+				}
+				log.Print(mismatchMessage(fmt.Sprintf("%s: Mismatch between Airtable and %s",
+					AIID, titleErr.Source), airTitle, titleErr))
+				return queueMismatch(ctx, reviews, r, titleErr.DOI, titleErr)
 			}
-			continue
-		}
-		if err := updateConfirmDOI(r, doi); err != nil {
-			return err
+			log.Printf("❌ %s: %s", AIID, err.Error())
+			if errors.Is(err, errNoRMDDOI) {
+				return result(r.ID, "", OUTCOME_NOT_FOUND), nil
+			}
+			return result(r.ID, rmdDOI, "").failed(ERR_API, err), nil
 		}
+		return updateConfirmDOI(r, rmdDOI, cite, match)
 	}
-	return nil
+
+	sum := newSummary(cmd.Name(), len(recs))
+	err = forEach(stopCtx, len(recs), workersFlag, func(i int) error {
+		res, err := confirmTask(recs[i])
+		sum.add(res)
+		return err
+	})
+	return sum.finish(err)
 }
 
-func confirmDOICrossRef(doi, title string) error {
-	if !resolvableDOI(doi) {
-		return fmt.Errorf("DOI does not resolve: %s", doi)
+// queueMismatch adds the mismatched candidate to the review queue and returns
+// the result for the task
+func queueMismatch(ctx context.Context, reviews *doiReviews, r *airtable.Record, d doi.DOI, titleErr *TitleMatchErr) (recordResult, error) {
+	if err := reviews.queue(ctx, r, titleErr); err != nil {
+		return result(r.ID, d, "").failed(ERR_AIRTABLE, err), err
 	}
-	doiMeta, err := crossref.GetCitation(doi)
+	return result(r.ID, d, OUTCOME_MISMATCHED), nil
+}
+
+// mismatchMessage returns a log message for a mismatch with the Airtable and
+// source titles and the reasons for the score, if any. Each mismatch is
+// logged with a single call so messages from concurrent workers don't
+// interleave.
+func mismatchMessage(summary, airTitle string, e *TitleMatchErr) string {
+	msg := fmt.Sprintf("❌ %s\n - Airtable: %s\n - %s: %s", summary, airTitle, e.Source, e.Got)
+	if e.Reason != "" {
+		msg += "\n - Reasons: " + e.Reason
+	}
+	return msg
+}
+
+// confirmDOIMeta returns the DOI's citation and match score if the DOI
+// resolves and the citation from its registration agency matches the work
+// with a score of at least minScore.
+func confirmDOIMeta(ctx context.Context, d doi.DOI, work workInfo, minScore float64) (*crossref.Citation, workMatch, error) {
+	if res := doiClient.Resolve(ctx, d); !res.Resolves() {
+		return nil, workMatch{}, fmt.Errorf("DOI does not resolve: %s", res.Reason())
+	}
+	doiMeta, err := getCitation(ctx, d)
 	if err != nil {
-		return err
+		return nil, workMatch{}, err
 	}
-	crossTitle := strings.Join(doiMeta.Title, ": ")
-	if !similarTitles(title, crossTitle) {
-		return &TitleMatchErr{
-			ID:       doi,
-			Source:   "CrossRef",
-			Expected: title,
-			Got:      crossTitle,
+	match := matchWork(work, doiMeta)
+	if match.Score < minScore {
+		return nil, match, &TitleMatchErr{
+			ID:       d.String(),
+			Source:   citationSource(doiMeta),
+			DOI:      d,
+			Expected: work.Title,
+			Got:      strings.Join(doiMeta.Title, ": "),
+			Score:    match.Score,
+			Reason:   match.Reason(),
 		}
 	}
-	return nil
+	return doiMeta, match, nil
 }
 
-func confirmRMD(rmdc *rmd.Client, AIID, title string) (string, error) {
-	rmdPubs, err := rmdc.PublicationsAI(AIID)
+func confirmRMD(ctx context.Context, rmdc *rmd.Client, AIID string, work workInfo, minScore float64) (doi.DOI, *crossref.Citation, workMatch, error) {
+	rmdPubs, err := rmdc.PublicationsAI(ctx, AIID)
 	if err != nil {
-		return "", fmt.Errorf(`RMD request failed for %s: %w`, AIID, err)
+		return "", nil, workMatch{}, fmt.Errorf(`RMD request failed for %s: %w`, AIID, err)
 	}
-	doi := cleanDOI(findPubDOI(rmdPubs))
+	rmdDOI := findPubDOI(rmdPubs)
 	if len(rmdPubs) > 0 {
 		rmdbTitle := rmdPubs[0].Attributes.CompleteTitle()
-		if !similarTitles(title, rmdbTitle) {
-			return "", &TitleMatchErr{
+		if !similarTitles(work.Title, rmdbTitle) {
+			return "", nil, workMatch{}, &TitleMatchErr{
 				ID:       AIID,
 				Source:   "RMD",
-				Expected: title,
+				DOI:      rmdDOI,
+				Expected: work.Title,
 				Got:      rmdbTitle,
+				Score:    titleSimilarity(work.Title, rmdbTitle),
 			}
 		}
 
 	}
-	if doi == "" {
-		return "", fmt.Errorf("No DOI for %s in RMD", AIID)
+	if rmdDOI == "" {
+		return "", nil, workMatch{}, fmt.Errorf("%s: %w", AIID, errNoRMDDOI)
 	}
-	if err := confirmDOICrossRef(doi, title); err != nil {
-		return "", err
+	cite, match, err := confirmDOIMeta(ctx, rmdDOI, work, minScore)
+	if err != nil {
+		return "", nil, match, err
 	}
-	return doi, nil
+	return rmdDOI, cite, match, nil
 }
 
 // titles similar
 func similarTitles(a, b string) bool {
-	// for comparison, remove white space and some characters
-	repl := strings.NewReplacer(
-		" ", "",
-		"\n", "",
-		",", "",
-		":", "",
-		"“", "",
-		"“", "",
-		`"`, "")
-	a = repl.Replace(strings.ToLower(a))
-	b = repl.Replace(strings.ToLower(b))
+	a, b = normTitle(a), normTitle(b)
 	if a == "" || b == "" {
 		return false
 	}
 	if strings.HasPrefix(a, b) || strings.HasPrefix(b, a) {
 		return true
 	}
-	simVal, err := edlib.StringsSimilarity(a, b, edlib.Levenshtein)
-	if err != nil {
-		return false
-	}
-	if simVal < 0.7 {
-		return false
-	}
-	return true
+	return titleSimilarity(a, b) >= 0.7
 }
 
-// update airtable to confirm doi
-func updateConfirmDOI(r *airtable.Record, doi string) error {
+// update airtable to confirm doi, with the match score and reasons. The
+// publication date from the citation is set if the task doesn't have one,
+// and retractions and corrections are recorded in Update_Status.
+func updateConfirmDOI(r *airtable.Record, d doi.DOI, cite *crossref.Citation, match workMatch) (recordResult, error) {
 	update := make(map[string]interface{})
-	update[COL_DOI] = doi
+	update[COL_DOI] = d.String()
 	update[COL_DOI_CONF] = true
+	update[COL_DOI_SCORE] = roundScore(match.Score)
+	update[COL_DOI_REASON] = match.Reason()
+	if status := updateStatusValue(cite); status != "" {
+		update[COL_UPDATE_STAT] = status
+		log.Printf("⚠️ %s: %s", d, status)
+	}
+	if pubDate, _ := r.Fields[COL_PUBDATE].(string); pubDate == "" && cite != nil {
+		if d := cite.PublicationDate().EDTF(); d != "" {
+			update[COL_PUBDATE] = d
+		}
+	}
 	_, err := r.UpdateRecordPartial(update)
 	if err != nil {
-		return fmt.Errorf("failed to confirm DOI: %w", err)
+		err = fmt.Errorf("failed to confirm DOI: %w", err)
+		return result(r.ID, d, "").failed(ERR_AIRTABLE, err), err
+	}
+	log.Printf("✅ confirmed: %s (score=%.2f)", d, match.Score)
+	return result(r.ID, d, OUTCOME_CONFIRMED).changed(update), nil
+}
+
+// citationSource returns the name of the citation's source for messages
+func citationSource(c *crossref.Citation) string {
+	if c.Source == "" {
+		return "CrossRef"
+	}
+	return c.Source
+}
+
+// roundScore rounds scores saved in Airtable to two decimal places
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// reviewStatus describes a review queue decision for logging
+func reviewStatus(decision string) string {
+	if decision == "" {
+		return "pending"
 	}
-	log.Printf("✅ confirmed: %s", doi)
-	return nil
+	return strings.ToLower(decision)
 }
 
 // extract DOIs from an RMD record
-func findPubDOI(pubs []rmd.Publication) string {
-	var dois []string
+func findPubDOI(pubs []rmd.Publication) doi.DOI {
+	var dois []doi.DOI
 	for _, p := range pubs {
-		dois = append(dois, strings.ToLower(p.Attributes.DOI))
+		d, _ := doi.Parse(p.Attributes.DOI)
+		dois = append(dois, d)
 	}
 	if len(dois) == 0 {
 		return ""
@@ -0,0 +1,222 @@
+package cmd
+
+// Optional deposit metadata (keywords, subjects, language, contributors, and
+// related URLs, including the DOI's landing page) is filled from CrossRef and RMD, as are the rights for Tasks
+// without a license in Airtable. Which sources are used for each field, and
+// in which order, can be set in the config file:
+//
+//  deposit:
+//    metadata:
+//      keyword: [rmd, crossref]
+//      subject: [crossref]
+//
+// For each field, values from the first source that has any are used.
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+// metadata sources. SRC_CROSSREF is the DOI's metadata, which may come from
+// DataCite or another registration agency.
+const (
+	SRC_CROSSREF = "crossref"
+	SRC_RMD      = "rmd"
+)
+
+// metadata fields that can be enriched
+const (
+	META_KEYWORD     = "keyword"
+	META_SUBJECT     = "subject"
+	META_LANGUAGE    = "language"
+	META_CONTRIBUTOR = "contributor"
+	META_RELATED_URL = "related_url"
+	META_RIGHTS      = "rights"
+)
+
+// defaultMetaSources are used for fields that are not in the configuration.
+// CrossRef only has subjects, so it isn't a default source for keywords.
+var defaultMetaSources = map[string][]string{
+	META_KEYWORD:     {SRC_RMD},
+	META_SUBJECT:     {SRC_CROSSREF},
+	META_LANGUAGE:    {SRC_CROSSREF},
+	META_CONTRIBUTOR: {SRC_CROSSREF},
+	META_RELATED_URL: {SRC_CROSSREF, SRC_RMD},
+	META_RIGHTS:      {SRC_CROSSREF},
+}
+
+// metaSources returns enrichment rules from the configuration, with defaults
+// for unset fields.
+func metaSources(conf map[string][]string) (map[string][]string, error) {
+	rules := make(map[string][]string)
+	for field, srcs := range defaultMetaSources {
+		rules[field] = srcs
+	}
+	for field, srcs := range conf {
+		if _, ok := defaultMetaSources[field]; !ok {
+			return nil, fmt.Errorf("unknown deposit metadata field in config: %s", field)
+		}
+		for _, s := range srcs {
+			if s != SRC_CROSSREF && s != SRC_RMD {
+				return nil, fmt.Errorf("unknown source for deposit metadata field %s: %s", field, s)
+			}
+		}
+		rules[field] = srcs
+	}
+	return rules, nil
+}
+
+// enrichMeta sets optional metadata fields, and the rights if they are
+// empty, using the citation and publication (either may be nil) according to
+// the rules. Fields that already have values are not changed.
+func enrichMeta(meta *scholargo.WorkMeta, cite *crossref.Citation, pub *rmd.Publication, rules map[string][]string) {
+	targets := map[string]*[]string{
+		META_KEYWORD:     &meta.Keyword,
+		META_SUBJECT:     &meta.Subject,
+		META_LANGUAGE:    &meta.Language,
+		META_CONTRIBUTOR: &meta.Contributor,
+		META_RELATED_URL: &meta.RelatedURL,
+	}
+	for field, target := range targets {
+		if len(*target) == 0 {
+			*target = metaValues(field, cite, pub, rules)
+		}
+	}
+	if meta.Rights == "" {
+		if vals := metaValues(META_RIGHTS, cite, pub, rules); len(vals) > 0 {
+			meta.Rights = vals[0]
+		}
+	}
+}
+
+// metaValues returns values for the field from the first source in the rules
+// that has any
+func metaValues(field string, cite *crossref.Citation, pub *rmd.Publication, rules map[string][]string) []string {
+	for _, src := range rules[field] {
+		var vals []string
+		switch src {
+		case SRC_CROSSREF:
+			vals = crossRefMeta(cite, field)
+		case SRC_RMD:
+			vals = rmdMeta(pub, field)
+		}
+		if vals = uniqueStrings(vals); len(vals) > 0 {
+			return vals
+		}
+	}
+	return nil
+}
+
+// crossRefMeta returns values for the metadata field from the citation
+func crossRefMeta(cite *crossref.Citation, field string) []string {
+	if cite == nil {
+		return nil
+	}
+	var vals []string
+	switch field {
+	case META_KEYWORD, META_SUBJECT:
+		vals = cite.Subject
+	case META_LANGUAGE:
+		if cite.Language != "" {
+			vals = []string{cite.Language}
+		}
+	case META_CONTRIBUTOR:
+		for _, f := range cite.Funder {
+			vals = append(vals, f.Name)
+		}
+	case META_RELATED_URL:
+		if d, err := doi.Parse(cite.DOI); err == nil {
+			vals = append(vals, d.URL())
+		}
+		// CrossRef's URL is the DOI link; other agencies give the landing page
+		if cite.URL != "" && !isDOILink(cite.URL) {
+			vals = append(vals, cite.URL)
+		}
+	case META_RIGHTS:
+		// licenses for the accepted manuscript (or all versions) that apply
+		// on publication and are used for deposits
+		for _, l := range cite.License {
+			if (l.ContentVersion != "am" && l.ContentVersion != "unspecified") || l.DelayInDays > 0 {
+				continue
+			}
+			if r := licenseRights(l.URL); r != "" {
+				vals = append(vals, r)
+			}
+		}
+	}
+	return vals
+}
+
+// isDOILink returns true if u is a doi.org link
+func isDOILink(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Host) {
+	case "doi.org", "dx.doi.org", "www.doi.org":
+		return true
+	}
+	return false
+}
+
+// licenseRights returns the deposit rights for a license URL, or "" if the
+// license isn't one used for deposits (see convertLicense). Scheme, "www.",
+// trailing slashes, and "legalcode" are ignored.
+func licenseRights(u string) string {
+	key := func(u string) string {
+		u = strings.ToLower(strings.TrimSpace(u))
+		u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+		u = strings.TrimPrefix(u, "www.")
+		u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), "/legalcode")
+		return strings.TrimSuffix(u, "/")
+	}
+	for _, l := range []string{"cc-by", "cc-by-nc", "cc-by-nc-sa", "cc-by-nc-nd", "cc0"} {
+		if r := convertLicense(l); key(r) == key(u) {
+			return r
+		}
+	}
+	return ""
+}
+
+// rmdMeta returns values for the metadata field from the RMD publication
+func rmdMeta(pub *rmd.Publication, field string) []string {
+	if pub == nil {
+		return nil
+	}
+	var vals []string
+	switch field {
+	case META_KEYWORD:
+		for _, t := range pub.Attributes.Tags {
+			vals = append(vals, t.Name)
+		}
+	case META_RELATED_URL:
+		// ScholarSphere links point back to the deposit itself
+		if u := pub.Attributes.OAURL; u != "" && !strings.HasPrefix(u, SSLinkPrefix) {
+			vals = append(vals, u)
+		}
+	}
+	return vals
+}
+
+// uniqueStrings removes empty and duplicate (ignoring case) values
+func uniqueStrings(vals []string) []string {
+	var ret []string
+	seen := make(map[string]bool)
+	for _, v := range vals {
+		v = strings.TrimSpace(v)
+		k := strings.ToLower(v)
+		if v == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		ret = append(ret, v)
+	}
+	return ret
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+func TestMetaSources(t *testing.T) {
+	table := []struct {
+		desc  string
+		conf  map[string][]string
+		field string
+		want  []string
+		err   bool
+	}{
+		{desc: "default", field: META_KEYWORD, want: []string{SRC_RMD}},
+		{desc: "default rights", field: META_RIGHTS, want: []string{SRC_CROSSREF}},
+		{desc: "configured", conf: map[string][]string{META_KEYWORD: {SRC_CROSSREF}}, field: META_KEYWORD, want: []string{SRC_CROSSREF}},
+		{desc: "default for unset field", conf: map[string][]string{META_KEYWORD: {SRC_CROSSREF}}, field: META_SUBJECT, want: []string{SRC_CROSSREF}},
+		{desc: "disabled", conf: map[string][]string{META_RELATED_URL: {}}, field: META_RELATED_URL, want: []string{}},
+		{desc: "unknown field", conf: map[string][]string{"color": {SRC_RMD}}, err: true},
+		{desc: "unknown source", conf: map[string][]string{META_LANGUAGE: {"orcid"}}, err: true},
+	}
+	for _, row := range table {
+		rules, err := metaSources(row.conf)
+		if row.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", row.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", row.desc, err)
+			continue
+		}
+		if got := rules[row.field]; !reflect.DeepEqual(got, row.want) {
+			t.Errorf("%s: expected %v, got %v", row.desc, row.want, got)
+		}
+	}
+	// defaults are not modified by configuration
+	if _, err := metaSources(map[string][]string{META_KEYWORD: {SRC_CROSSREF}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(defaultMetaSources[META_KEYWORD], []string{SRC_RMD}) {
+		t.Errorf("defaults changed: %v", defaultMetaSources[META_KEYWORD])
+	}
+}
+
+func TestEnrichMeta(t *testing.T) {
+	cite := &crossref.Citation{
+		DOI:      "10.1000/abc",
+		URL:      "http://dx.doi.org/10.1000/abc",
+		Subject:  []string{"Geology", "geology", " "},
+		Language: "en",
+		Funder:   []crossref.Funder{{Name: "NSF"}, {Name: "NIH"}},
+		License:  []crossref.License{{URL: "https://example.com/tdm", ContentVersion: "tdm"}},
+	}
+	licensed := *cite
+	licensed.License = []crossref.License{
+		{URL: "https://example.com/tdm", ContentVersion: "tdm"},
+		{URL: "http://creativecommons.org/licenses/by/4.0/", ContentVersion: "vor"},
+		{URL: "https://creativecommons.org/licenses/by-nc/4.0/legalcode", ContentVersion: "am", DelayInDays: 365},
+		{URL: "http://www.creativecommons.org/licenses/by-nc-nd/4.0", ContentVersion: "am"},
+	}
+	landing := *cite
+	landing.URL = "https://data.example.org/abc"
+	var pub rmd.Publication
+	err := json.Unmarshal([]byte(`{"attributes": {
+		"preferred_open_access_url": "https://arxiv.org/abs/1234",
+		"Tags": [{"name": "Soils"}, {"name": "Carbon"}]
+	}}`), &pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ssPub rmd.Publication
+	ssPub.Attributes.OAURL = SSLinkPrefix + "abc"
+
+	defaults, err := metaSources(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossFirst, err := metaSources(map[string][]string{
+		META_KEYWORD:     {SRC_CROSSREF, SRC_RMD},
+		META_RELATED_URL: {SRC_RMD, SRC_CROSSREF},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := []struct {
+		desc  string
+		meta  scholargo.WorkMeta
+		cite  *crossref.Citation
+		pub   *rmd.Publication
+		rules map[string][]string
+		want  scholargo.WorkMeta
+	}{
+		{
+			desc: "defaults", cite: cite, pub: &pub, rules: defaults,
+			want: scholargo.WorkMeta{
+				Keyword:     []string{"Soils", "Carbon"},
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc"},
+			},
+		},
+		{
+			desc: "source order", cite: cite, pub: &pub, rules: crossFirst,
+			want: scholargo.WorkMeta{
+				Keyword:     []string{"Geology"},
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://arxiv.org/abs/1234"},
+			},
+		},
+		{
+			desc: "fallback without RMD", cite: cite, rules: defaults,
+			want: scholargo.WorkMeta{
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc"},
+			},
+		},
+		{
+			desc: "fallback without citation", pub: &pub, rules: defaults,
+			want: scholargo.WorkMeta{
+				Keyword:    []string{"Soils", "Carbon"},
+				RelatedURL: []string{"https://arxiv.org/abs/1234"},
+			},
+		},
+		{
+			desc: "ScholarSphere link not related", pub: &ssPub, rules: defaults,
+			want: scholargo.WorkMeta{},
+		},
+		{
+			desc: "landing page", cite: &landing, rules: defaults,
+			want: scholargo.WorkMeta{
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc", "https://data.example.org/abc"},
+			},
+		},
+		{
+			desc: "rights from accepted manuscript license", cite: &licensed, rules: defaults,
+			want: scholargo.WorkMeta{
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc"},
+				Rights:      "https://creativecommons.org/licenses/by-nc-nd/4.0/",
+			},
+		},
+		{
+			desc: "existing rights kept", cite: &licensed, rules: defaults,
+			meta: scholargo.WorkMeta{Rights: "https://creativecommons.org/licenses/by/4.0/"},
+			want: scholargo.WorkMeta{
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc"},
+				Rights:      "https://creativecommons.org/licenses/by/4.0/",
+			},
+		},
+		{
+			desc: "existing values kept", cite: cite, pub: &pub, rules: defaults,
+			meta: scholargo.WorkMeta{Keyword: []string{"Existing"}},
+			want: scholargo.WorkMeta{
+				Keyword:     []string{"Existing"},
+				Subject:     []string{"Geology"},
+				Language:    []string{"en"},
+				Contributor: []string{"NSF", "NIH"},
+				RelatedURL:  []string{"https://doi.org/10.1000/abc"},
+			},
+		},
+	}
+	for _, row := range table {
+		meta := row.meta
+		enrichMeta(&meta, row.cite, row.pub, row.rules)
+		if !reflect.DeepEqual(meta, row.want) {
+			t.Errorf("%s: expected %+v, got %+v", row.desc, row.want, meta)
+		}
+	}
+}
+
+func TestRMDMeta(t *testing.T) {
+	var pub rmd.Publication
+	pub.Attributes.OAURL = "https://arxiv.org/abs/1234"
+	table := []struct {
+		desc  string
+		pub   *rmd.Publication
+		field string
+		want  []string
+	}{
+		{desc: "nil publication", field: META_RELATED_URL},
+		{desc: "related url", pub: &pub, field: META_RELATED_URL, want: []string{"https://arxiv.org/abs/1234"}},
+		{desc: "no tags", pub: &pub, field: META_KEYWORD},
+		{desc: "unsupported field", pub: &pub, field: META_LANGUAGE},
+	}
+	for _, row := range table {
+		if got := rmdMeta(row.pub, row.field); !reflect.DeepEqual(got, row.want) {
+			t.Errorf("%s: expected %v, got %v", row.desc, row.want, got)
+		}
+	}
+}
+
+func TestLicenseRights(t *testing.T) {
+	table := []struct {
+		url  string
+		want string
+	}{
+		{"https://creativecommons.org/licenses/by/4.0/", "https://creativecommons.org/licenses/by/4.0/"},
+		{"http://creativecommons.org/licenses/by-nc-sa/4.0/legalcode", "https://creativecommons.org/licenses/by-nc-sa/4.0/"},
+		{"https://creativecommons.org/publicdomain/zero/1.0", "http://creativecommons.org/publicdomain/zero/1.0/"},
+		{"https://creativecommons.org/licenses/by/3.0/", ""},
+		{"https://www.elsevier.com/tdm/userlicense/1.0/", ""},
+		{"", ""},
+	}
+	for _, row := range table {
+		if got := licenseRights(row.url); got != row.want {
+			t.Errorf("%q: expected %q, got %q", row.url, row.want, got)
+		}
+	}
+}
+
+func TestUniqueStrings(t *testing.T) {
+	table := []struct {
+		in   []string
+		want []string
+	}{
+		{in: nil, want: nil},
+		{in: []string{"", " "}, want: nil},
+		{in: []string{" Soils ", "soils", "SOILS", "Carbon"}, want: []string{"Soils", "Carbon"}},
+	}
+	for _, row := range table {
+		if got := uniqueStrings(row.in); !reflect.DeepEqual(got, row.want) {
+			t.Errorf("uniqueStrings(%q): expected %q, got %q", row.in, row.want, got)
+		}
+	}
+}
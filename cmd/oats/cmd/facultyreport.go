@@ -0,0 +1,444 @@
+package cmd
+
+// The faculty-report command prints a report of a faculty member's works in
+// RMD, showing which are open, deposited, in progress, blocked on permissions,
+// awaiting a manuscript, or not tracked in Airtable ("no task"). It combines
+// the user's publications in RMD with the status of the corresponding Tasks in
+// Airtable (matched by Activity Insight ID or DOI), OA status from Unpaywall,
+// and ScholarSphere links from Airtable, RMD, and ScholarSphere. With --all,
+// reports are created for every user with Tasks in Airtable. With --dir, each
+// report is saved to a separate file named for the user (the directory is
+// created if needed); otherwise reports are written to stdout.
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+// work categories, in the order they are reported
+const (
+	WORK_DEPOSITED   = "deposited"              // in ScholarSphere
+	WORK_OPEN        = "open"                   // open access elsewhere (Unpaywall)
+	WORK_IN_PROGRESS = "in progress"            // Task has a manuscript and permissions
+	WORK_BLOCKED     = "blocked on permissions" // publisher doesn't allow deposit
+	WORK_AWAITING    = "awaiting manuscript"    // no manuscript in Activity Insight
+	WORK_UNTRACKED   = "no task"                // not tracked in Airtable
+)
+
+var workCategories = []string{WORK_DEPOSITED, WORK_OPEN, WORK_IN_PROGRESS, WORK_BLOCKED, WORK_AWAITING, WORK_UNTRACKED}
+
+var facultyReportFlags struct {
+	all    bool
+	dir    string
+	format string
+}
+
+var facultyReportCmd = &coral.Command{
+	Use:   "faculty-report [USERNAME...]",
+	Short: "Print OA status reports for faculty members' works in RMD",
+	Long: `The faculty-report command prints a report of a faculty member's works in
+RMD, showing which are open, deposited, in progress, blocked on permissions,
+awaiting a manuscript, or not tracked in Airtable ("no task"). It combines
+the user's publications in RMD with the status of the corresponding Tasks in
+Airtable (matched by Activity Insight ID or DOI), OA status from Unpaywall,
+and ScholarSphere links from Airtable, RMD, and ScholarSphere. With --all,
+reports are created for every user with Tasks in Airtable. With --dir, each
+report is saved to a separate file named for the user (the directory is
+created if needed); otherwise reports are written to stdout.`,
+	RunE: runFacultyReport,
+}
+
+func init() {
+	addWorkersFlag(facultyReportCmd)
+	rootCmd.AddCommand(facultyReportCmd)
+	facultyReportCmd.Flags().BoolVarP(&facultyReportFlags.all, "all", "", false, "create reports for all users with Tasks")
+	facultyReportCmd.Flags().StringVarP(&facultyReportFlags.dir, "dir", "", "", "save each report to a file in the directory")
+	facultyReportCmd.Flags().StringVarP(&facultyReportFlags.format, "format", "f", REPORT_TABLE, "report format: table, csv, or html")
+}
+
+// facultyWork is a work in a faculty report
+type facultyWork struct {
+	Title       string
+	Journal     string
+	Published   string
+	DOI         doi.DOI
+	TaskStatus  string // empty if the work has no Task
+	Permissions string
+	OAStatus    string // from Unpaywall
+	Link        string // ScholarSphere link
+	Category    string
+}
+
+// facultyReport is the report for a user
+type facultyReport struct {
+	User      string
+	Generated time.Time
+	Works     []facultyWork // by category, then newest first
+	Counts    []reportCount // by category
+}
+
+// facultyTask is the Task information used for reports
+type facultyTask struct {
+	status   string
+	perm     string
+	link     string
+	hasFile  bool
+	hasAIRec bool
+}
+
+// facultyData is the information from Airtable and ScholarSphere used for all
+// reports
+type facultyData struct {
+	byAIID  map[string]*facultyTask // by Activity Insight ID
+	byDOI   map[doi.DOI]*facultyTask
+	users   []string // users with Tasks
+	scholar scholargo.DOIMap
+}
+
+func runFacultyReport(cmd *coral.Command, args []string) error {
+	if err := rejectOutput(cmd); err != nil {
+		return err
+	}
+	var render func(io.Writer, []*facultyReport) error
+	var ext string
+	switch facultyReportFlags.format {
+	case REPORT_TABLE:
+		render, ext = renderFacultyTable, ".txt"
+	case REPORT_CSV:
+		render, ext = renderFacultyCSV, ".csv"
+	case REPORT_HTML:
+		render, ext = renderFacultyHTML, ".html"
+	default:
+		return fmt.Errorf("invalid --format %q: expected %s, %s, or %s", facultyReportFlags.format, REPORT_TABLE, REPORT_CSV, REPORT_HTML)
+	}
+	if len(args) == 0 && !facultyReportFlags.all {
+		return errors.New("expected USERNAME or --all")
+	}
+	ctx := cmd.Context()
+	data, err := loadFacultyData(ctx)
+	if err != nil {
+		return err
+	}
+	users := args
+	if facultyReportFlags.all {
+		users = data.users
+	}
+	// always use rmd production data
+	rmdbC := newRMDClient(oats.RMDB.Production)
+	unclient := newUnpaywallClient()
+
+	if facultyReportFlags.dir != "" {
+		if err := os.MkdirAll(facultyReportFlags.dir, 0755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	var reports []*facultyReport
+	var failed int
+	for _, user := range users {
+		if stopCtx.Err() != nil {
+			err = errInterrupted
+			break
+		}
+		user = strings.ToLower(user)
+		pubs, rerr := rmdbC.UserPublications(ctx, user)
+		if rerr != nil {
+			log.Printf("❌ %s: failed to get publications from RMD: %s", user, rerr)
+			failed++
+			continue
+		}
+		works := make([]facultyWork, len(pubs))
+		// Unpaywall lookups for works that haven't been deposited
+		err = forEach(stopCtx, len(pubs), workersFlag, func(i int) error {
+			works[i] = data.work(pubs[i])
+			if works[i].DOI == "" || works[i].Category == WORK_DEPOSITED {
+				return nil
+			}
+			info, err := unclient.GetDOI(ctx, works[i].DOI)
+			if err != nil {
+				log.Printf("⚠️ %s: Unpaywall error: %s", works[i].DOI, err)
+				return nil
+			}
+			works[i].OAStatus = info.OAStatus
+			if isOpen(info.OAStatus) {
+				works[i].Category = WORK_OPEN
+			}
+			return nil
+		})
+		if err != nil {
+			break
+		}
+		rep := newFacultyReport(user, works)
+		log.Printf("✅ %s: %d works in RMD", user, len(works))
+		if facultyReportFlags.dir == "" {
+			reports = append(reports, rep)
+			continue
+		}
+		if werr := writeFacultyReport(filepath.Join(facultyReportFlags.dir, user+ext), rep, render); werr != nil {
+			log.Printf("❌ %s: %s", user, werr)
+			failed++
+		}
+	}
+	if len(reports) > 0 {
+		if rerr := render(os.Stdout, reports); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if err == nil && failed > 0 {
+		err = fmt.Errorf("%w: reports for %d users", errRecordsFailed, failed)
+	}
+	return stopErr(err)
+}
+
+// loadFacultyData gets Tasks and Activity Insight records from Airtable and
+// DOIs from ScholarSphere
+func loadFacultyData(ctx context.Context) (*facultyData, error) {
+	data := &facultyData{
+		byAIID: make(map[string]*facultyTask),
+		byDOI:  make(map[doi.DOI]*facultyTask),
+	}
+	aiRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, "", []string{COL_ID, "POST_FILE_1_DOC"})
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	aiIDs := make(map[string]string) // Airtable record ID -> Activity Insight ID
+	hasFile := make(map[string]bool) // by Airtable record ID
+	for _, ai := range aiRecs {
+		aiIDs[ai.ID], _ = ai.Fields[COL_ID].(string)
+		file, _ := ai.Fields["POST_FILE_1_DOC"].(string)
+		hasFile[ai.ID] = file != ""
+	}
+	cols := []string{COL_AI_ID, COL_DOI, COL_STATUS, COL_PERM, COL_SCHOLINK, COL_USER}
+	tasks, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, "", cols)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	users := make(map[string]bool)
+	for _, t := range tasks {
+		ft := &facultyTask{}
+		ft.status, _ = t.Fields[COL_STATUS].(string)
+		ft.perm, _ = t.Fields[COL_PERM].(string)
+		ft.link, _ = t.Fields[COL_SCHOLINK].(string)
+		if ids, _ := t.Fields[COL_AI_ID].([]interface{}); len(ids) == 1 {
+			recID, _ := ids[0].(string)
+			ft.hasAIRec = true
+			ft.hasFile = hasFile[recID]
+			if id := aiIDs[recID]; id != "" {
+				data.byAIID[id] = ft
+			}
+		}
+		airDOI, _ := t.Fields[COL_DOI].(string)
+		if d, err := doi.Parse(airDOI); err == nil {
+			data.byDOI[d] = ft
+		}
+		if user, _ := t.Fields[COL_USER].(string); user != "" {
+			users[strings.ToLower(user)] = true
+		}
+	}
+	for u := range users {
+		data.users = append(data.users, u)
+	}
+	sort.Strings(data.users)
+
+	server := oats.ScholarSphere.Test
+	if oats.Production {
+		server = oats.ScholarSphere.Production
+	}
+	data.scholar, err = newScholarClient(server).DOIs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get current DOIs from ScholarSphere: %w`, err)
+	}
+	return data, nil
+}
+
+// work returns the work for the RMD publication, without the OA status
+func (data *facultyData) work(pub rmd.Publication) facultyWork {
+	attrs := pub.Attributes
+	w := facultyWork{
+		Title:     attrs.Title,
+		Journal:   attrs.JournalTitle,
+		Published: attrs.PublishedOn,
+	}
+	if attrs.SecondaryTitle != "" {
+		w.Title += ": " + attrs.SecondaryTitle
+	}
+	w.DOI, _ = doi.Parse(attrs.DOI)
+	var task *facultyTask
+	for _, id := range attrs.ActivityInsightIDS {
+		if task = data.byAIID[id]; task != nil {
+			break
+		}
+	}
+	if task == nil && w.DOI != "" {
+		task = data.byDOI[w.DOI]
+	}
+	if task != nil {
+		w.TaskStatus = task.status
+		w.Permissions = task.perm
+		w.Link = task.link
+	}
+	if w.Link == "" && strings.HasPrefix(attrs.OAURL, SSLinkPrefix) {
+		w.Link = attrs.OAURL
+	}
+	if w.Link == "" && w.DOI != "" {
+		if ids := data.scholar.Find(w.DOI); len(ids) > 0 {
+			w.Link = SSLinkPrefix + ids[0]
+		}
+	}
+	w.Category = workCategory(w, task)
+	return w
+}
+
+// workCategory returns the category for the work, before checking Unpaywall
+func workCategory(w facultyWork, task *facultyTask) string {
+	switch {
+	case w.Link != "":
+		return WORK_DEPOSITED
+	case task == nil:
+		return WORK_UNTRACKED
+	case task.perm != "" && task.perm != PERM_OPEN:
+		return WORK_BLOCKED
+	case task.hasAIRec && !task.hasFile:
+		return WORK_AWAITING
+	}
+	return WORK_IN_PROGRESS
+}
+
+// isOpen returns true if the Unpaywall OA status is open access
+func isOpen(oaStatus string) bool {
+	return oaStatus != "" && oaStatus != "closed"
+}
+
+// newFacultyReport sorts the works and counts them by category
+func newFacultyReport(user string, works []facultyWork) *facultyReport {
+	order := make(map[string]int)
+	for i, c := range workCategories {
+		order[c] = i
+	}
+	sort.SliceStable(works, func(i, j int) bool {
+		if works[i].Category != works[j].Category {
+			return order[works[i].Category] < order[works[j].Category]
+		}
+		return works[i].Published > works[j].Published
+	})
+	rep := &facultyReport{User: user, Generated: time.Now(), Works: works}
+	counts := make(map[string]int)
+	for _, w := range works {
+		counts[w.Category]++
+	}
+	for _, c := range workCategories {
+		if counts[c] > 0 {
+			rep.Counts = append(rep.Counts, reportCount{Value: c, Count: counts[c], Share: percent(counts[c], len(works))})
+		}
+	}
+	return rep
+}
+
+// writeFacultyReport saves the report to a file
+func writeFacultyReport(name string, rep *facultyReport, render func(io.Writer, []*facultyReport) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := render(f, []*facultyReport{rep}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renderFacultyTable writes the reports as plain-text tables
+func renderFacultyTable(w io.Writer, reps []*facultyReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, rep := range reps {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "OA status report for %s (%s)\n\n", rep.User, rep.Generated.Format("2006-01-02"))
+		for _, c := range rep.Counts {
+			fmt.Fprintf(tw, "  %s\t%d\t%.1f%%\n", c.Value, c.Count, c.Share)
+		}
+		fmt.Fprintf(tw, "  total\t%d\t\n", len(rep.Works))
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "Category\tPublished\tTitle\tDOI\tTask\tOA status")
+		for _, wk := range rep.Works {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", wk.Category, wk.Published, shorten(wk.Title, 60), wk.DOI, wk.TaskStatus, wk.OAStatus)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shorten truncates s to n runes
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// renderFacultyCSV writes the reports as CSV with a row for each work
+func renderFacultyCSV(w io.Writer, reps []*facultyReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"user", "category", "published", "title", "journal", "doi", "task_status", "permissions", "oa_status", "scholarsphere_link"})
+	for _, rep := range reps {
+		for _, wk := range rep.Works {
+			cw.Write([]string{rep.User, wk.Category, wk.Published, wk.Title, wk.Journal, wk.DOI.String(), wk.TaskStatus, wk.Permissions, wk.OAStatus, wk.Link})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderFacultyHTML writes the reports as a standalone HTML page
+func renderFacultyHTML(w io.Writer, reps []*facultyReport) error {
+	return facultyTmpl.Execute(w, reps)
+}
+
+var facultyTmpl = template.Must(template.New("faculty").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>OA Status Report{{if eq (len .) 1}}: {{(index . 0).User}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+{{range .}}<h1>OA Status Report: {{.User}}</h1>
+<p>Generated {{.Generated.Format "2006-01-02"}}</p>
+<table>
+{{range .Counts}}<tr><td>{{.Value}}</td><td class="num">{{.Count}}</td><td class="num">{{printf "%.1f%%" .Share}}</td></tr>
+{{end}}<tr><th>total</th><th class="num">{{len .Works}}</th><th></th></tr>
+</table>
+<table>
+<tr><th>Category</th><th>Published</th><th>Title</th><th>Journal</th><th>DOI</th><th>Task</th><th>OA status</th></tr>
+{{range .Works}}<tr><td>{{.Category}}</td><td>{{.Published}}</td><td>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</td><td>{{.Journal}}</td><td>{{if .DOI}}<a href="{{.DOI.URL}}">{{.DOI}}</a>{{end}}</td><td>{{.TaskStatus}}</td><td>{{.OAStatus}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+func TestFacultyWork(t *testing.T) {
+	data := &facultyData{
+		byAIID: map[string]*facultyTask{
+			"1": {status: "To Deposit", perm: PERM_OPEN, hasAIRec: true, hasFile: true},
+			"2": {status: "In Progress", perm: PERM_CLOSED, hasAIRec: true, hasFile: true},
+			"3": {status: "In Progress", hasAIRec: true},
+		},
+		byDOI: map[doi.DOI]*facultyTask{
+			"10.1000/4": {status: "Deposited", link: SSLinkPrefix + "abc"},
+		},
+		scholar: scholargo.DOIMap{"doi:10.1000/5": {"def"}},
+	}
+	pub := func(aiID, d, title string) rmd.Publication {
+		var p rmd.Publication
+		p.Attributes.Title = title
+		p.Attributes.DOI = d
+		if aiID != "" {
+			p.Attributes.ActivityInsightIDS = []string{aiID}
+		}
+		return p
+	}
+	table := []struct {
+		pub      rmd.Publication
+		category string
+		link     string
+	}{
+		{pub("1", "10.1000/1", "A"), WORK_IN_PROGRESS, ""},
+		{pub("2", "", "B"), WORK_BLOCKED, ""},
+		{pub("3", "", "C"), WORK_AWAITING, ""},
+		{pub("", "https://doi.org/10.1000/4", "D"), WORK_DEPOSITED, SSLinkPrefix + "abc"},
+		{pub("", "10.1000/5", "E"), WORK_DEPOSITED, SSLinkPrefix + "def"},
+		{pub("", "10.1000/6", "F"), WORK_UNTRACKED, ""},
+	}
+	for _, row := range table {
+		w := data.work(row.pub)
+		if w.Category != row.category || w.Link != row.link {
+			t.Errorf("%s: expected %q (link=%q), got %q (link=%q)", row.pub.Attributes.Title, row.category, row.link, w.Category, w.Link)
+		}
+	}
+}
+
+func TestFacultyReport(t *testing.T) {
+	rep := newFacultyReport("abc123", []facultyWork{
+		{Title: "Old", Published: "2019-01-01", Category: WORK_AWAITING},
+		{Title: "New", Published: "2021-01-01", Category: WORK_AWAITING},
+		{Title: "Open", Published: "2020-01-01", Category: WORK_OPEN, DOI: "10.1000/1", OAStatus: "gold"},
+	})
+	if rep.Works[0].Title != "Open" || rep.Works[1].Title != "New" {
+		t.Errorf("unexpected order: %+v", rep.Works)
+	}
+	if len(rep.Counts) != 2 || rep.Counts[1].Value != WORK_AWAITING || rep.Counts[1].Count != 2 {
+		t.Errorf("unexpected counts: %+v", rep.Counts)
+	}
+	var buf bytes.Buffer
+	if err := renderFacultyCSV(&buf, []*facultyReport{rep}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "abc123,open,2020-01-01,Open,,10.1000/1,,,gold,\n") {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+	for _, render := range []func(*bytes.Buffer) error{
+		func(b *bytes.Buffer) error { return renderFacultyTable(b, []*facultyReport{rep}) },
+		func(b *bytes.Buffer) error { return renderFacultyHTML(b, []*facultyReport{rep}) },
+	} {
+		buf.Reset()
+		if err := render(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "abc123") || !strings.Contains(buf.String(), "awaiting manuscript") {
+			t.Errorf("unexpected report:\n%s", buf.String())
+		}
+	}
+}
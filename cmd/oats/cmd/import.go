@@ -81,13 +81,14 @@ func init() {
 
 func runImport(cmd *coral.Command, args []string) error {
 	if oats.Production {
-		fmt.Println(`importing csv to production airtable:`, oats.AirtableBase())
+		log.Println(`importing csv to production airtable:`, oats.AirtableBase())
 	} else {
-		fmt.Println(`importing csv to testing airtable:`, oats.AirtableBase())
+		log.Println(`importing csv to testing airtable:`, oats.AirtableBase())
 	}
 	if len(args) == 0 {
 		return errors.New("expected csv file argument")
 	}
+	ctx := cmd.Context()
 	csvFile := args[0]
 	file, err := os.Open(csvFile)
 	if err != nil {
@@ -132,7 +133,7 @@ func runImport(cmd *coral.Command, args []string) error {
 	}
 	log.Println("Indexing existing Activity Insight entries ...")
 	// get list of IDs from airtable
-	currRecs, err := oats.GetRecordsFilterFields(oats.Airtable.ActivityInsight, ``, []string{COL_ID})
+	currRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, ``, []string{COL_ID})
 	if err != nil {
 		return fmt.Errorf(`failed to retrieve IDS from %s Airtable: %w`, oats.Airtable.ActivityInsight, err)
 	}
@@ -140,35 +141,57 @@ func runImport(cmd *coral.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("in Activity Insight Airtable: %w", err)
 	}
+	sum := newSummary(cmd.Name(), len(importRecs))
+	err = sum.finish(importRecords(importRecs, currByID, sum))
+	if err != nil {
+		return err
+	}
+	log.Println("Use 'tasks' command to create corresponding Task entries")
+	return nil
+}
+
+// importRecords updates existing Activity Insight records and creates new
+// ones. It stops after the first interrupt.
+func importRecords(importRecs map[string]map[string]interface{}, currByID map[string][]*airtable.Record, sum *runSummary) error {
 	var toCreate []*airtable.Record
-	var numUpdated int
 	for id, fields := range importRecs {
+		if stopCtx.Err() != nil {
+			return errInterrupted
+		}
 		prevs, exists := currByID[id]
 		if !exists {
 			toCreate = append(toCreate, &airtable.Record{Fields: fields})
 			continue
 		}
 		if len(prevs) > 1 {
-			return fmt.Errorf(`found duplicate Activity Insight entries in Airtable: %s`, id)
+			err := fmt.Errorf(`found duplicate Activity Insight entries in Airtable: %s`, id)
+			sum.add(result(id, "", "").failed(ERR_INPUT, err))
+			return err
 		}
 		if len(prevs) == 0 {
-			return fmt.Errorf(`DEBUG: table index shouldn't have empty entries %s`, id)
+			err := fmt.Errorf(`DEBUG: table index shouldn't have empty entries %s`, id)
+			sum.add(result(id, "", "").failed(ERR_INPUT, err))
+			return err
 		}
 		prev := prevs[0]
 		if _, err := prev.UpdateRecordPartial(fields); err != nil {
+			sum.add(result(id, "", "").failed(ERR_AIRTABLE, err))
 			return fmt.Errorf("failed to update record with ID %s: %w", id, err)
 		}
-		fmt.Printf("updated Activity Insight ID: %s\n", id)
-		numUpdated++
+		log.Printf("updated Activity Insight ID: %s", id)
+		sum.add(result(id, "", OUTCOME_UPDATED).changed(fields))
 	}
-	// create new records in the Activity Insight airtable
-	created, err := oats.PostRecords(oats.Airtable.ActivityInsight, toCreate)
-	if err != nil {
+	// create new records in the Activity Insight airtable, stopping between
+	// batches if interrupted
+	created, err := oats.PostRecords(stopCtx, oats.Airtable.ActivityInsight, toCreate)
+	for _, rec := range created {
+		id, _ := rec.Fields[COL_ID].(string)
+		sum.add(result(id, "", OUTCOME_CREATED).changed(rec.Fields))
+	}
+	if err = stopErr(err); err != nil && !errors.Is(err, errInterrupted) {
 		return fmt.Errorf(`failed to create new airtable records: %w`, err)
 	}
-	fmt.Printf("Created %d and Updated %d Activity Insight Records in Airtable.\n", len(created), numUpdated)
-	fmt.Println("Use 'tasks' command to create corresponding Task entries")
-	return nil
+	return err
 }
 
 // converts the row to a Fields for Airtable
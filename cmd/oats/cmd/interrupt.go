@@ -0,0 +1,71 @@
+package cmd
+
+// Interrupts: the first interrupt (Ctrl-C) or SIGTERM stops commands from
+// starting new records. Records in progress are finished and the command
+// prints a summary of what was done (see summary.go). A second interrupt
+// cancels the command's context, abandoning requests in progress, and a third
+// exits immediately.
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// stopCtx is canceled by the first interrupt. Commands check it before
+// starting each record; requests use the command's context (cmd.Context()).
+var stopCtx = context.Background()
+
+// errInterrupted is returned by commands that stopped because of an interrupt
+var errInterrupted = errors.New("interrupted")
+
+// handleInterrupts sets stopCtx and returns the context for commands. The
+// returned function restores the default signal behavior.
+func handleInterrupts() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stop, stopCancel := context.WithCancel(ctx)
+	stopCtx = stop
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		log.Println("⚠️ interrupted: finishing records in progress (interrupt again to cancel them)")
+		stopCancel()
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		log.Println("⚠️ interrupted: canceling requests in progress")
+		cancel()
+		signal.Stop(sigs)
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		stopCancel()
+		cancel()
+	}
+}
+
+// stopErr returns errInterrupted if err was caused by stopCtx being canceled
+func stopErr(err error) error {
+	if err != nil && stopCtx.Err() != nil && errors.Is(err, stopCtx.Err()) {
+		return errInterrupted
+	}
+	return err
+}
+
+// defaultInterrupts restores the default signal behavior, for interactive
+// commands that should exit as soon as they are interrupted.
+func defaultInterrupts() {
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+}
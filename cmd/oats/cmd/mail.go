@@ -0,0 +1,286 @@
+package cmd
+
+// Commands that email faculty render messages from text/template templates
+// and either send them through the SMTP server in the config file or save
+// them as .eml files in an outbox directory (--outbox), which can be
+// reviewed and opened in a mail client. Templates define the message body;
+// the subject is set by defining a "subject" template. Built-in templates can
+// be replaced with files set in the email.templates config.
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/muesli/coral"
+)
+
+// default domain for recipient addresses
+const defaultEmailDomain = "psu.edu"
+
+// mailFlags are the flags for commands that send email
+var mailFlags struct {
+	outbox string
+	send   bool
+}
+
+// addMailFlags adds the --outbox and --send flags to the command
+func addMailFlags(cmd *coral.Command) {
+	cmd.Flags().StringVarP(&mailFlags.outbox, "outbox", "", "", "save messages as .eml files in the directory")
+	cmd.Flags().BoolVarP(&mailFlags.send, "send", "", false, "send messages using the configured SMTP server")
+}
+
+// emailMessage is a message to send
+type emailMessage struct {
+	To      []string
+	Cc      []string
+	Subject string
+	Body    string
+}
+
+// mailer delivers messages by SMTP or to an outbox directory. Sending a
+// message times out after --timeout.
+type mailer struct {
+	from     *mail.Address
+	replyTo  *mail.Address
+	outbox   string // save messages here if set
+	smtpHost string // host:port
+	auth     smtp.Auth
+}
+
+// newMailer returns a mailer for the --outbox and --send flags
+func newMailer() (*mailer, error) {
+	conf := oats.Email
+	if (mailFlags.outbox == "" && !mailFlags.send) || (mailFlags.outbox != "" && mailFlags.send) {
+		return nil, errors.New("expected either --outbox or --send")
+	}
+	if conf.From == "" {
+		return nil, errors.New("email.from is not set in the config file")
+	}
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email.from: %w", err)
+	}
+	m := &mailer{from: from, outbox: mailFlags.outbox}
+	if conf.ReplyTo != "" {
+		if m.replyTo, err = mail.ParseAddress(conf.ReplyTo); err != nil {
+			return nil, fmt.Errorf("invalid email.reply_to: %w", err)
+		}
+	}
+	if mailFlags.send {
+		if conf.SMTP.Host == "" {
+			return nil, errors.New("email.smtp.host is not set in the config file")
+		}
+		host, _, err := net.SplitHostPort(conf.SMTP.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email.smtp.host (expected host:port): %w", err)
+		}
+		m.smtpHost = conf.SMTP.Host
+		if conf.SMTP.Username != "" {
+			m.auth = smtp.PlainAuth("", conf.SMTP.Username, conf.SMTP.Password, host)
+		}
+		return m, nil
+	}
+	if err := os.MkdirAll(m.outbox, 0755); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// deliver sends the message or saves it to the outbox. name is used for the
+// outbox file name. It returns the file name or the recipients.
+func (m *mailer) deliver(ctx context.Context, name string, msg *emailMessage) (string, error) {
+	data, err := m.format(msg, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if m.outbox != "" {
+		file := filepath.Join(m.outbox, fileSafe(name)+".eml")
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return "", err
+		}
+		return file, nil
+	}
+	rcpts := append(append([]string(nil), msg.To...), msg.Cc...)
+	if err := m.send(ctx, rcpts, data); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	return strings.Join(rcpts, ", "), nil
+}
+
+// send sends the message with the SMTP server, using STARTTLS if the server
+// supports it (like smtp.SendMail). The connection is closed if it takes
+// longer than --timeout or if ctx is canceled.
+func (m *mailer) send(ctx context.Context, rcpts []string, data []byte) error {
+	host, _, err := net.SplitHostPort(m.smtpHost)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: rootFlags.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.smtpHost)
+	if err != nil {
+		return err
+	}
+	if rootFlags.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(rootFlags.timeout))
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from.Address); err != nil {
+		return err
+	}
+	for _, addr := range rcpts {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// format returns the message with headers. The body is quoted-printable.
+func (m *mailer) format(msg *emailMessage, date time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", m.from.String())
+	header("To", strings.Join(msg.To, ", "))
+	if len(msg.Cc) > 0 {
+		header("Cc", strings.Join(msg.Cc, ", "))
+	}
+	if m.replyTo != nil {
+		header("Reply-To", m.replyTo.String())
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", messageID(m.from.Address, date))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a unique Message-ID using the sender's domain
+func messageID(from string, date time.Time) string {
+	domain := "oats"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	rnd := make([]byte, 8)
+	rand.Read(rnd)
+	return fmt.Sprintf("<%d.%s@%s>", date.UnixNano(), hex.EncodeToString(rnd), domain)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileSafe replaces characters that aren't safe in file names
+func fileSafe(name string) string {
+	return unsafeFileChars.ReplaceAllString(name, "_")
+}
+
+// userEmail returns the email address for the user (username@domain)
+func userEmail(user string) string {
+	domain := oats.Email.Domain
+	if domain == "" {
+		domain = defaultEmailDomain
+	}
+	return strings.ToLower(user) + "@" + domain
+}
+
+// loadMailTemplate returns the template with the name in the email.templates
+// config, or the default template. Templates must define the subject with a
+// "subject" template.
+func loadMailTemplate(name, def string) (*template.Template, error) {
+	src := def
+	if file := oats.Email.Templates[name]; file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s template: %w", name, err)
+		}
+		src = string(b)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	if tmpl.Lookup("subject") == nil {
+		return nil, fmt.Errorf(`invalid %s template: no "subject" template`, name)
+	}
+	return tmpl, nil
+}
+
+// renderMessage executes the template with the data
+func renderMessage(tmpl *template.Template, data interface{}) (*emailMessage, error) {
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	return &emailMessage{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimLeft(body.String(), "\n"),
+	}, nil
+}
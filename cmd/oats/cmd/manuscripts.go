@@ -0,0 +1,259 @@
+package cmd
+
+// The request-manuscripts command emails faculty to ask for the accepted
+// manuscripts of their articles. It selects active Tasks with
+// 'Permissions'='Accepted Version OK' and no ScholarSphere link whose
+// Activity Insight record has no POST_FILE_1_DOC, groups them by User, and
+// renders a message for each user from the "manuscript_request" template (see
+// mail.go). Messages are saved to an outbox directory (--outbox) or sent with
+// the configured SMTP server (--send). Usernames can be given as arguments to
+// limit the users that are emailed. When a message is sent, the date is saved
+// in the Tasks' Manuscript_Requested column, and Tasks with a date are not
+// included again. Saving messages to the outbox doesn't set the date.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
+)
+
+// name of the template in the email.templates config
+const TMPL_MANUSCRIPT = "manuscript_request"
+
+// Tasks column with the date a manuscript was requested
+const COL_MS_REQUESTED = "Manuscript_Requested"
+
+// outcomes for messages
+const (
+	OUTCOME_SENT  = "sent"
+	OUTCOME_SAVED = "saved"
+)
+
+var manuscriptsCmd = &coral.Command{
+	Use:   "request-manuscripts [USERNAME...]",
+	Short: "Email faculty to request accepted manuscripts",
+	Long: `The request-manuscripts command emails faculty to ask for the accepted
+manuscripts of their articles. It selects active Tasks with
+'Permissions'='Accepted Version OK' and no ScholarSphere link whose
+Activity Insight record has no POST_FILE_1_DOC, groups them by User, and
+renders a message for each user from the "manuscript_request" template.
+Messages are saved to an outbox directory (--outbox) or sent with the
+configured SMTP server (--send). Usernames can be given as arguments to
+limit the users that are emailed.
+
+When a message is sent, the date is saved in the Tasks' Manuscript_Requested
+column (a date field), and Tasks with a date are not included in later runs.
+To request a manuscript again, clear the date. Saving messages to the outbox
+doesn't set the date, so running with --outbox and then --send is safe.
+
+Templates use Go's text/template syntax and must define a "subject"
+template. Template data:
+  .User   username
+  .Name   name from Activity Insight
+  .Email  recipient address
+  .Tasks  list of Tasks with .Title, .Journal, .DOI, .Permissions,
+          .License, .Embargo, .Statement, and .ActivityInsightID`,
+	RunE: runRequestManuscripts,
+}
+
+func init() {
+	addMailFlags(manuscriptsCmd)
+	rootCmd.AddCommand(manuscriptsCmd)
+}
+
+// manuscriptRequest is the template data for a manuscript request
+type manuscriptRequest struct {
+	User  string
+	Name  string
+	Email string
+	Tasks []manuscriptTask
+}
+
+// manuscriptTask is a Task in a manuscript request
+type manuscriptTask struct {
+	Title             string
+	Journal           string
+	DOI               doi.DOI
+	Permissions       string // publisher policy
+	License           string
+	Embargo           string // embargo end date
+	Statement         string // publisher's set statement
+	ActivityInsightID string
+
+	rec *airtable.Record // Task record
+}
+
+func runRequestManuscripts(cmd *coral.Command, args []string) error {
+	ctx := cmd.Context()
+	m, err := newMailer()
+	if err != nil {
+		return err
+	}
+	tmpl, err := loadMailTemplate(TMPL_MANUSCRIPT, defaultManuscriptTmpl)
+	if err != nil {
+		return err
+	}
+	reqs, err := loadManuscriptRequests(ctx, args)
+	if err != nil {
+		return err
+	}
+	log.Printf("Found %d users with Tasks awaiting manuscripts", len(reqs))
+
+	sum := newSummary(cmd.Name(), len(reqs))
+	for _, req := range reqs {
+		if stopCtx.Err() != nil {
+			return sum.finish(errInterrupted)
+		}
+		msg, err := renderMessage(tmpl, req)
+		if err != nil {
+			// template errors affect all messages
+			sum.add(result(req.User, "", "").failed(ERR_INPUT, err))
+			return sum.finish(fmt.Errorf("failed to render %s template: %w", TMPL_MANUSCRIPT, err))
+		}
+		msg.To = []string{req.Email}
+		dest, err := m.deliver(ctx, req.User, msg)
+		if err != nil {
+			log.Printf("❌ %s: %s", req.User, err)
+			sum.add(result(req.User, "", "").failed(ERR_API, err))
+			continue
+		}
+		if m.outbox != "" {
+			log.Printf("✅ %s: %d tasks, %s %s", req.User, len(req.Tasks), OUTCOME_SAVED, dest)
+			sum.add(result(req.User, "", OUTCOME_SAVED))
+			continue
+		}
+		log.Printf("✅ %s: %d tasks, %s %s", req.User, len(req.Tasks), OUTCOME_SENT, dest)
+		update, err := markRequested(req, time.Now())
+		if err != nil {
+			// the message was sent, so the user may be emailed again
+			log.Printf("❌ %s: failed to save %s: %s", req.User, COL_MS_REQUESTED, err)
+			sum.add(result(req.User, "", OUTCOME_SENT).failed(ERR_AIRTABLE, err))
+			continue
+		}
+		sum.add(result(req.User, "", OUTCOME_SENT).changed(update))
+	}
+	return sum.finish(nil)
+}
+
+// loadManuscriptRequests returns requests for Tasks awaiting manuscripts that
+// haven't been requested. If users is not empty, only requests for those
+// users are returned.
+func loadManuscriptRequests(ctx context.Context, users []string) ([]*manuscriptRequest, error) {
+	aiCols := []string{COL_ID, "POST_FILE_1_DOC", "First Name", "Last Name"}
+	aiRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, "", aiCols)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	aiByRecID := make(map[string]*airtable.Record)
+	for _, ai := range aiRecs {
+		aiByRecID[ai.ID] = ai
+	}
+	filter := fmt.Sprintf(`AND({%s}="%s",{%s}!="Complete",{%s}!="Deposited",LEN({%s})<4,{%s}="")`,
+		COL_PERM, PERM_OPEN, COL_STATUS, COL_STATUS, COL_SCHOLINK, COL_MS_REQUESTED)
+	cols := []string{COL_AI_ID, COL_USER, COL_TITLE, COL_JOURNAL, COL_DOI, COL_PERM, COL_LICENSE, COL_EMBARGO, COL_STMNT}
+	tasks, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, cols)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	return manuscriptRequests(tasks, aiByRecID, users), nil
+}
+
+// markRequested saves the request date to the request's Tasks. It returns the
+// update.
+func markRequested(req *manuscriptRequest, date time.Time) (map[string]interface{}, error) {
+	update := map[string]interface{}{COL_MS_REQUESTED: date.Format("2006-01-02")}
+	for _, t := range req.Tasks {
+		if _, err := t.rec.UpdateRecordPartial(update); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Title, err)
+		}
+	}
+	return update, nil
+}
+
+// manuscriptRequests groups Tasks without manuscripts by user. If users is not
+// empty, only requests for those users are returned.
+func manuscriptRequests(tasks []*airtable.Record, aiByRecID map[string]*airtable.Record, users []string) []*manuscriptRequest {
+	only := make(map[string]bool)
+	for _, u := range users {
+		only[strings.ToLower(u)] = true
+	}
+	byUser := make(map[string]*manuscriptRequest)
+	for _, t := range tasks {
+		user, _ := t.Fields[COL_USER].(string)
+		user = strings.ToLower(user)
+		if user == "" || (len(only) > 0 && !only[user]) {
+			continue
+		}
+		ids, _ := t.Fields[COL_AI_ID].([]interface{})
+		if len(ids) != 1 {
+			continue
+		}
+		recID, _ := ids[0].(string)
+		ai := aiByRecID[recID]
+		if ai == nil {
+			continue
+		}
+		if file, _ := ai.Fields["POST_FILE_1_DOC"].(string); file != "" {
+			continue
+		}
+		req := byUser[user]
+		if req == nil {
+			first, _ := ai.Fields["First Name"].(string)
+			last, _ := ai.Fields["Last Name"].(string)
+			req = &manuscriptRequest{
+				User:  user,
+				Name:  strings.TrimSpace(first + " " + last),
+				Email: userEmail(user),
+			}
+			byUser[user] = req
+		}
+		mt := manuscriptTask{rec: t}
+		mt.Title, _ = t.Fields[COL_TITLE].(string)
+		mt.Journal, _ = t.Fields[COL_JOURNAL].(string)
+		airDOI, _ := t.Fields[COL_DOI].(string)
+		mt.DOI, _ = doi.Parse(airDOI)
+		mt.Permissions, _ = t.Fields[COL_PERM].(string)
+		mt.License, _ = t.Fields[COL_LICENSE].(string)
+		mt.Embargo, _ = t.Fields[COL_EMBARGO].(string)
+		mt.Statement, _ = t.Fields[COL_STMNT].(string)
+		mt.ActivityInsightID, _ = ai.Fields[COL_ID].(string)
+		req.Tasks = append(req.Tasks, mt)
+	}
+	var reqs []*manuscriptRequest
+	for _, req := range byUser {
+		sort.Slice(req.Tasks, func(i, j int) bool { return req.Tasks[i].Title < req.Tasks[j].Title })
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].User < reqs[j].User })
+	return reqs
+}
+
+// defaultManuscriptTmpl is the built-in manuscript_request template
+var defaultManuscriptTmpl = `{{define "subject"}}Request for accepted manuscripts{{if gt (len .Tasks) 1}} ({{len .Tasks}} articles){{end}}{{end}}
+Dear {{if .Name}}{{.Name}}{{else}}colleague{{end}},
+
+The {{if gt (len .Tasks) 1}}publishers of the following articles allow{{else}}publisher of the following article allows{{end}} the
+accepted manuscript (the final version after peer review, before the publisher's
+formatting) to be shared openly in ScholarSphere, Penn State's open access
+repository. Could you reply to this message with the accepted manuscript
+{{- if gt (len .Tasks) 1}} of each article{{end}}? We'll take care of the deposit.
+{{range .Tasks}}
+- {{.Title}}
+{{- if .Journal}}
+  {{.Journal}}{{end}}
+{{- if .DOI}}
+  https://doi.org/{{.DOI}}{{end}}
+  Publisher policy: {{.Permissions}}
+{{- if .License}}, license: {{.License}}{{end}}
+{{- if .Embargo}}, embargo until {{.Embargo}}{{end}}
+{{end}}
+Thank you,
+Open Access Team
+`
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/cmd/oats/base"
+	"github.com/psu-libraries/oats/mockserver"
+)
+
+func TestManuscriptRequests(t *testing.T) {
+	defer setTestOats()()
+	rec := func(id string, fields map[string]interface{}) *airtable.Record {
+		return &airtable.Record{ID: id, Fields: fields}
+	}
+	aiByRecID := map[string]*airtable.Record{
+		"rec1": rec("rec1", map[string]interface{}{COL_ID: "1", "First Name": "Ada", "Last Name": "Lovelace"}),
+		"rec2": rec("rec2", map[string]interface{}{COL_ID: "2", "POST_FILE_1_DOC": "file.pdf"}),
+		"rec3": rec("rec3", map[string]interface{}{COL_ID: "3", "First Name": "Ada", "Last Name": "Lovelace"}),
+		"rec4": rec("rec4", map[string]interface{}{COL_ID: "4"}),
+	}
+	task := func(user, aiRecID, title string) *airtable.Record {
+		return rec("", map[string]interface{}{
+			COL_USER:  user,
+			COL_AI_ID: []interface{}{aiRecID},
+			COL_TITLE: title,
+			COL_DOI:   "https://doi.org/10.1000/" + aiRecID,
+			COL_PERM:  PERM_OPEN,
+		})
+	}
+	tasks := []*airtable.Record{
+		task("AXL1", "rec3", "B"),
+		task("axl1", "rec1", "A"),
+		task("bxb2", "rec2", "has file"),
+		task("cxc3", "rec4", "C"),
+		task("dxd4", "missing", "no AI record"),
+	}
+	reqs := manuscriptRequests(tasks, aiByRecID, nil)
+	if len(reqs) != 2 || reqs[0].User != "axl1" || reqs[1].User != "cxc3" {
+		t.Fatalf("unexpected requests: %+v", reqs)
+	}
+	ada := reqs[0]
+	if ada.Name != "Ada Lovelace" || ada.Email != "axl1@psu.edu" {
+		t.Errorf("unexpected name or email: %q, %q", ada.Name, ada.Email)
+	}
+	if len(ada.Tasks) != 2 || ada.Tasks[0].Title != "A" || ada.Tasks[0].DOI != "10.1000/rec1" || ada.Tasks[0].ActivityInsightID != "1" {
+		t.Errorf("unexpected tasks: %+v", ada.Tasks)
+	}
+	reqs = manuscriptRequests(tasks, aiByRecID, []string{"CXC3"})
+	if len(reqs) != 1 || reqs[0].User != "cxc3" {
+		t.Errorf("expected only cxc3, got %+v", reqs)
+	}
+}
+
+func TestManuscriptMessage(t *testing.T) {
+	defer setTestOats()()
+	tmpl, err := loadMailTemplate(TMPL_MANUSCRIPT, defaultManuscriptTmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &manuscriptRequest{
+		User:  "axl1",
+		Name:  "Ada Lovelace",
+		Email: "axl1@psu.edu",
+		Tasks: []manuscriptTask{
+			{Title: "On Engines", Journal: "Notes", DOI: "10.1000/1", Permissions: PERM_OPEN, Embargo: "2023-01-01"},
+			{Title: "On Numbers", Permissions: PERM_OPEN, License: "cc-by"},
+		},
+	}
+	msg, err := renderMessage(tmpl, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Request for accepted manuscripts (2 articles)" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	for _, want := range []string{
+		"Dear Ada Lovelace,",
+		"- On Engines\n  Notes\n  https://doi.org/10.1000/1\n  Publisher policy: Accepted Version OK, embargo until 2023-01-01\n",
+		"- On Numbers\n  Publisher policy: Accepted Version OK, license: cc-by\n",
+	} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("expected %q in body:\n%s", want, msg.Body)
+		}
+	}
+
+	// save to outbox
+	msg.To = []string{req.Email}
+	from, _ := mail.ParseAddress("OA Team <oa@example.com>")
+	m := &mailer{from: from, outbox: t.TempDir()}
+	file, err := m.deliver(context.Background(), "axl1/request", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(file) != "axl1_request.eml" {
+		t.Errorf("unexpected file name: %s", file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parsed, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header.Get("To") != "axl1@psu.edu" || parsed.Header.Get("From") != `"OA Team" <oa@example.com>` {
+		t.Errorf("unexpected headers: %v", parsed.Header)
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMailerSend(t *testing.T) {
+	prevTimeout := rootFlags.timeout
+	defer func() { rootFlags.timeout = prevTimeout }()
+	rootFlags.timeout = 500 * time.Millisecond
+	from, _ := mail.ParseAddress("oa@example.com")
+	msg := &emailMessage{To: []string{"axl1@psu.edu"}, Cc: []string{"staff@example.com"}, Subject: "Hello", Body: "Hi"}
+	ctx := context.Background()
+
+	var smtpSrv mockserver.SMTPServer
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go smtpSrv.Serve(ln)
+	m := &mailer{from: from, smtpHost: ln.Addr().String()}
+	rcpts, err := m.deliver(ctx, "axl1", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcpts != "axl1@psu.edu, staff@example.com" {
+		t.Errorf("unexpected recipients: %s", rcpts)
+	}
+	if msgs := smtpSrv.Messages(); len(msgs) != 1 || msgs[0].From != "oa@example.com" || len(msgs[0].To) != 2 {
+		t.Errorf("unexpected messages: %+v", msgs)
+	}
+
+	// a server that never responds
+	stalled, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	go func() {
+		for {
+			conn, err := stalled.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	m.smtpHost = stalled.Addr().String()
+	start := time.Now()
+	if _, err := m.deliver(ctx, "axl1", msg); err == nil {
+		t.Error("expected error for stalled server")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected send to time out after %s, took %s", rootFlags.timeout, d)
+	}
+	rootFlags.timeout = time.Minute
+	cctx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := m.deliver(cctx, "axl1", msg); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled send, got %v", err)
+	}
+}
+
+func TestMailTemplate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "custom.tmpl")
+	defer setTestOats()()
+	oats.Email.Templates = map[string]string{"custom": file}
+	if err := os.WriteFile(file, []byte("no subject"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadMailTemplate("custom", ""); err == nil {
+		t.Error("expected error for template without subject")
+	}
+	src := `{{define "subject"}}Hi {{.User}}{{end}}{{.Missing}}`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadMailTemplate("custom", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renderMessage(tmpl, map[string]string{"User": "axl1"}); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
+
+// setTestOats sets oats with an empty config and returns a func to restore it
+func setTestOats() func() {
+	prev := oats
+	oats = &base.Oats{Config: &base.Config{}}
+	return func() { oats = prev }
+}
+
+func TestLoadManuscriptRequests(t *testing.T) {
+	task := func(id, aiRecID string, fields map[string]interface{}) mockserver.Record {
+		rec := mockserver.Record{ID: id, Fields: map[string]interface{}{
+			COL_USER:  "axl1",
+			COL_AI_ID: []interface{}{aiRecID},
+			COL_TITLE: id,
+			COL_PERM:  PERM_OPEN,
+		}}
+		for k, v := range fields {
+			rec.Fields[k] = v
+		}
+		return rec
+	}
+	srv := newMockOats(t, map[string][]mockserver.Record{
+		"Activity Insight": {
+			{ID: "recAI1", Fields: map[string]interface{}{COL_ID: "1"}},
+			{ID: "recAI2", Fields: map[string]interface{}{COL_ID: "2", "POST_FILE_1_DOC": "file.pdf"}},
+		},
+		"Tasks": {
+			task("recTask1", "recAI1", nil),
+			task("recTask2", "recAI1", map[string]interface{}{COL_MS_REQUESTED: "2022-01-01"}),
+			task("recTask3", "recAI2", nil),
+			task("recTask4", "recAI1", map[string]interface{}{COL_STATUS: "Deposited"}),
+			task("recTask5", "recAI1", map[string]interface{}{COL_PERM: PERM_CLOSED}),
+		},
+	})
+	ctx := context.Background()
+	reqs, err := loadManuscriptRequests(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || len(reqs[0].Tasks) != 1 || reqs[0].Tasks[0].Title != "recTask1" {
+		t.Fatalf("expected request for recTask1, got %+v", reqs)
+	}
+	date := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := markRequested(reqs[0], date); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Records(mockBase, "Tasks")[0].Fields[COL_MS_REQUESTED]; got != "2022-03-04" {
+		t.Errorf("expected request date, got %v", got)
+	}
+	// not requested again
+	reqs, err = loadManuscriptRequests(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 0 {
+		t.Errorf("expected no requests, got %+v", reqs)
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+// Matching Tasks to CrossRef citations: the title, journal, publication
+// year, volume/issue, and faculty member's last name are compared to produce
+// a confidence score (0-1) and a list of reasons. The score is used to confirm
+// existing DOIs and to choose DOIs found by discovery.
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hbollon/go-edlib"
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/crossref"
+)
+
+// Activity Insight columns used for matching
+const (
+	AI_LAST_NAME = "Last Name"
+	AI_PUB_YEAR  = "DTY_PUB"
+	AI_VOLUME    = "VOLUME"
+	AI_ISSUE     = "ISSUE"
+	AI_TITLE     = "TITLE"
+	AI_JOURNAL   = "JOURNAL_NAME"
+)
+
+// weights for each signal in the match score
+const (
+	weightTitle   = 0.5
+	weightJournal = 0.15
+	weightYear    = 0.15
+	weightVolume  = 0.1
+	weightAuthor  = 0.1
+)
+
+// titles shorter than this (after normalisation) are too generic to match on
+// their own
+const shortTitleLen = 30
+
+// workInfo is bibliographic information about a Task's work
+type workInfo struct {
+	Title    string
+	Journal  string
+	LastName string // faculty member's last name
+	Year     int    // publication year
+	Volume   string
+	Issue    string
+}
+
+// newWorkInfo returns workInfo using the Task and its Activity Insight record
+func newWorkInfo(task, ai *airtable.Record) workInfo {
+	var w workInfo
+	w.Title, _ = task.Fields[COL_TITLE].(string)
+	w.Journal, _ = task.Fields[COL_JOURNAL].(string)
+	if ai != nil {
+		w.LastName, _ = ai.Fields[AI_LAST_NAME].(string)
+		w.Volume, _ = ai.Fields[AI_VOLUME].(string)
+		w.Issue, _ = ai.Fields[AI_ISSUE].(string)
+		year, _ := ai.Fields[AI_PUB_YEAR].(string)
+		w.Year, _ = strconv.Atoi(strings.TrimSpace(year))
+	}
+	return w
+}
+
+// workMatch is the result of comparing a work to a citation
+type workMatch struct {
+	Score   float64
+	Reasons []string
+}
+
+// Reason returns the reasons for the score as a single string
+func (m workMatch) Reason() string {
+	return strings.Join(m.Reasons, "; ")
+}
+
+// matchWork compares the work to the citation. Signals the work doesn't have
+// (e.g., no volume) are left out of the score.
+func matchWork(w workInfo, c *crossref.Citation) workMatch {
+	var (
+		m            workMatch
+		score, total float64
+		corroborated bool // a signal other than the title matched
+	)
+	add := func(weight, val float64, reason string) {
+		score += weight * val
+		total += weight
+		m.Reasons = append(m.Reasons, reason)
+	}
+
+	titleSim := citationTitleSimilarity(w.Title, c)
+	add(weightTitle, titleSim, fmt.Sprintf("title %.2f", titleSim))
+
+	if w.Journal != "" && len(c.ContainerTitle)+len(c.ShortContainerTitle) > 0 {
+		var sim float64
+		for _, j := range append(c.ContainerTitle, c.ShortContainerTitle...) {
+			sim = math.Max(sim, titleSimilarity(w.Journal, j))
+		}
+		add(weightJournal, sim, fmt.Sprintf("journal %.2f", sim))
+		corroborated = corroborated || sim >= 0.8
+	}
+
+	if pub := c.PublicationDate(); w.Year > 0 && !pub.IsZero() {
+		// the work may have been reported with its print or online year
+		diff := abs(w.Year - pub.Parts[0])
+		for _, d := range []crossref.Date{c.PublishedOnline, c.PublishedPrint, c.Issued} {
+			if !d.IsZero() && abs(w.Year-d.Parts[0]) < diff {
+				diff = abs(w.Year - d.Parts[0])
+			}
+		}
+		switch diff {
+		case 0:
+			add(weightYear, 1, fmt.Sprintf("year matches (%d)", w.Year))
+			corroborated = true
+		case 1:
+			add(weightYear, 0.5, fmt.Sprintf("year off by one (%d)", w.Year))
+		default:
+			add(weightYear, 0, fmt.Sprintf("year differs (%d/%d)", w.Year, pub.Parts[0]))
+		}
+	}
+
+	if w.Volume != "" && c.Volume != "" {
+		vol := strings.EqualFold(strings.TrimSpace(w.Volume), c.Volume)
+		iss := w.Issue == "" || c.Issue == "" || strings.EqualFold(strings.TrimSpace(w.Issue), c.Issue)
+		if vol && iss {
+			add(weightVolume, 1, "volume/issue match")
+			corroborated = true
+		} else {
+			add(weightVolume, 0, fmt.Sprintf("volume/issue differ (%s(%s)/%s(%s))", w.Volume, w.Issue, c.Volume, c.Issue))
+		}
+	}
+
+	if w.LastName != "" && len(c.Author) > 0 {
+		last := newPersonName("", w.LastName, "")
+		found := false
+		for _, a := range c.Author {
+			if familyMatch(newPersonName(a.Given, a.Family, a.Name).family, last.family) {
+				found = true
+				break
+			}
+		}
+		if found {
+			add(weightAuthor, 1, fmt.Sprintf("author %s found", w.LastName))
+			corroborated = true
+		} else {
+			add(weightAuthor, 0, fmt.Sprintf("author %s not found", w.LastName))
+		}
+	}
+
+	m.Score = score / total
+	if len(normTitle(w.Title)) < shortTitleLen && !corroborated && m.Score > 0.5 {
+		// a short, generic title needs some other evidence
+		m.Score = 0.5
+		m.Reasons = append(m.Reasons, "short title without other evidence")
+	}
+	return m
+}
+
+// citationTitleSimilarity compares title to the citation's title, with and
+// without its subtitle. A title that is a prefix of the other (e.g., missing
+// a subtitle) is considered very similar.
+func citationTitleSimilarity(title string, c *crossref.Citation) float64 {
+	main := crossref.AbstractText(strings.Join(c.Title, ": ")) // titles may have markup
+	full := main
+	if len(c.Subtitle) > 0 {
+		full += ": " + crossref.AbstractText(strings.Join(c.Subtitle, ": "))
+	}
+	sim := math.Max(titleSimilarity(title, main), titleSimilarity(title, full))
+	a, b := normTitle(title), normTitle(full)
+	if len(a) >= shortTitleLen && len(b) >= shortTitleLen && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+		sim = math.Max(sim, 0.95)
+	}
+	return sim
+}
+
+// normTitle returns title for comparison: lower case letters and digits
+// only, without diacritics.
+func normTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(stripMarks(title)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// titleSimilarity returns the Levenshtein similarity (0-1) of the
+// normalised titles.
+func titleSimilarity(a, b string) float64 {
+	a, b = normTitle(a), normTitle(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	simVal, err := edlib.StringsSimilarity(a, b, edlib.Levenshtein)
+	if err != nil {
+		return 0
+	}
+	return float64(simVal)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+func TestMatchWork(t *testing.T) {
+	cite := &crossref.Citation{
+		Title:          []string{"Soil Carbon Dynamics in Agricultural Landscapes"},
+		Subtitle:       []string{"A Twenty-Year Study"},
+		ContainerTitle: []string{"Journal of Environmental Quality"},
+		Author: []crossref.Author{
+			{Given: "José", Family: "Núñez"},
+			{Given: "A.", Family: "Smith"},
+		},
+		Volume:          "48",
+		Issue:           "3",
+		PublishedPrint:  crossref.Date{Parts: []int{2019, 5}},
+		PublishedOnline: crossref.Date{Parts: []int{2018, 12, 1}},
+	}
+	short := &crossref.Citation{
+		Title:          []string{"Introduction"},
+		ContainerTitle: []string{"Some Other Journal"},
+		Issued:         crossref.Date{Parts: []int{2005}},
+	}
+	table := []struct {
+		desc string
+		work workInfo
+		cite *crossref.Citation
+		min  float64 // score is at least
+		max  float64 // score is at most
+	}{
+		{
+			desc: "all signals match",
+			work: workInfo{Title: "Soil carbon dynamics in agricultural landscapes", Journal: "J. Environmental Quality", LastName: "Nunez", Year: 2019, Volume: "48", Issue: "3"},
+			cite: cite, min: 0.9, max: 1,
+		},
+		{
+			desc: "title with subtitle and no other info",
+			work: workInfo{Title: "Soil Carbon Dynamics in Agricultural Landscapes: a twenty-year study"},
+			cite: cite, min: 0.9, max: 1,
+		},
+		{
+			desc: "online year",
+			work: workInfo{Title: "Soil carbon dynamics in agricultural landscapes", Year: 2018},
+			cite: cite, min: 0.9, max: 1,
+		},
+		{
+			desc: "different work",
+			work: workInfo{Title: "Nitrogen leaching under cover crops in Pennsylvania", Journal: "Agronomy Journal", LastName: "Jones", Year: 2012},
+			cite: cite, min: 0, max: 0.4,
+		},
+		{
+			desc: "short title, nothing else matches",
+			work: workInfo{Title: "Introduction", Journal: "Journal of Environmental Quality", Year: 2019},
+			cite: short, min: 0, max: 0.5,
+		},
+		{
+			desc: "short title without other evidence",
+			work: workInfo{Title: "Introduction"},
+			cite: short, min: 0.5, max: 0.5,
+		},
+	}
+	for _, row := range table {
+		m := matchWork(row.work, row.cite)
+		if m.Score < row.min || m.Score > row.max {
+			t.Errorf("%s: expected score in [%.2f, %.2f], got %.2f (%s)", row.desc, row.min, row.max, m.Score, m.Reason())
+		}
+		if len(m.Reasons) == 0 {
+			t.Errorf("%s: expected reasons", row.desc)
+		}
+	}
+}
+
+func TestNormTitle(t *testing.T) {
+	if a, b := normTitle("Über die Théorie—der Zahlen!"), "uberdietheoriederzahlen"; a != b {
+		t.Errorf("expected %q, got %q", b, a)
+	}
+}
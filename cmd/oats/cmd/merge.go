@@ -6,13 +6,16 @@ package cmd
 // it is used to join rows in the CSV file to rows in the Tasks table.
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 
 	"github.com/dimchansky/utfbom"
+	"github.com/mehanizm/airtable"
 	"github.com/muesli/coral"
 	"github.com/psu-libraries/oats/cmd/oats/base"
 )
@@ -36,12 +39,13 @@ func runMerge(cmd *coral.Command, args []string) error {
 	if len(args) == 0 {
 		return errors.New("expected csv file argument")
 	}
+	ctx := cmd.Context()
 	csvFile := args[0]
 
 	if oats.Production {
-		fmt.Println(`merging csv to production airtable:`, oats.AirtableBase())
+		log.Println(`merging csv to production airtable:`, oats.AirtableBase())
 	} else {
-		fmt.Println(`merging csv to testing airtable:`, oats.AirtableBase())
+		log.Println(`merging csv to testing airtable:`, oats.AirtableBase())
 	}
 
 	file, err := os.Open(csvFile)
@@ -93,7 +97,7 @@ func runMerge(cmd *coral.Command, args []string) error {
 	}
 
 	// get list of IDs from airtable
-	currRecs, err := oats.GetRecordsFilterFields(oats.Airtable.ActivityInsight, ``, []string{COL_ID, "Tasks"})
+	currRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, ``, []string{COL_ID, "Tasks"})
 	if err != nil {
 		return fmt.Errorf(`failed to retrieve IDS from %s Airtable: %w`, oats.Airtable.ActivityInsight, err)
 	}
@@ -102,38 +106,30 @@ func runMerge(cmd *coral.Command, args []string) error {
 		return err
 	}
 
+	sum := newSummary(cmd.Name(), len(updateRecs))
+	err = mergeRecords(ctx, updateRecs, indexCurr, sum)
+	return sum.finish(err)
+}
+
+// mergeRecords updates the Tasks for the Activity Insight IDs. It stops after
+// the first interrupt.
+func mergeRecords(ctx context.Context, updateRecs map[string]map[string]interface{}, indexCurr map[string][]*airtable.Record, sum *runSummary) error {
 	for id, fields := range updateRecs {
+		if stopCtx.Err() != nil {
+			return errInterrupted
+		}
 		prevs, present := indexCurr[id]
 		if !present {
-			fmt.Printf("ID %s doesn't exist\n", id)
+			log.Printf("ID %s doesn't exist", id)
+			sum.add(result(id, "", OUTCOME_SKIPPED))
 			continue
 		}
-		if len(prevs) != 1 {
-			return fmt.Errorf(`expected exactly one entry in the Activity Insight Airtable with the id: %s`, id)
-		}
-		prev := prevs[0]
-		taskIDs, ok := prev.Fields["Tasks"].([]interface{})
-		if !ok {
-			return fmt.Errorf("failed to get Task information for %s", id)
-		}
-		if len(taskIDs) == 0 {
-			return fmt.Errorf("no Task associated with ID %s", id)
-		}
-		taskID, ok := taskIDs[0].(string)
-		if !ok {
-			return fmt.Errorf("failed to get Task information for %s", id)
-		}
-		rec, err := oats.GetRecord(oats.Airtable.Tasks, taskID)
-		if err != nil {
+		if err := mergeRecord(ctx, id, fields, prevs); err != nil {
+			sum.add(result(id, "", "").failed(ERR_AIRTABLE, err))
 			return err
 		}
-		delete(fields, COL_ID)
-		_, err = rec.UpdateRecordPartial(fields)
-		if err != nil {
-			return fmt.Errorf("failed to update record with ID %s: %w", id, err)
-		}
-		fmt.Printf("updated Activity Insight entry %s\n", id)
-		continue
+		sum.add(result(id, "", OUTCOME_UPDATED).changed(fields))
+		log.Printf("✅ updated Activity Insight entry %s", id)
 	}
 
 	// // create new records in the Activity Insight airtable
@@ -146,3 +142,32 @@ func runMerge(cmd *coral.Command, args []string) error {
 
 	return nil
 }
+
+// mergeRecord updates the Task for the Activity Insight entry with the fields
+func mergeRecord(ctx context.Context, id string, fields map[string]interface{}, prevs []*airtable.Record) error {
+	if len(prevs) != 1 {
+		return withCategory(ERR_INPUT, fmt.Errorf(`expected exactly one entry in the Activity Insight Airtable with the id: %s`, id))
+	}
+	prev := prevs[0]
+	taskIDs, ok := prev.Fields["Tasks"].([]interface{})
+	if !ok {
+		return withCategory(ERR_INPUT, fmt.Errorf("failed to get Task information for %s", id))
+	}
+	if len(taskIDs) == 0 {
+		return withCategory(ERR_INPUT, fmt.Errorf("no Task associated with ID %s", id))
+	}
+	taskID, ok := taskIDs[0].(string)
+	if !ok {
+		return withCategory(ERR_INPUT, fmt.Errorf("failed to get Task information for %s", id))
+	}
+	rec, err := oats.GetRecord(ctx, oats.Airtable.Tasks, taskID)
+	if err != nil {
+		return err
+	}
+	delete(fields, COL_ID)
+	_, err = rec.UpdateRecordPartial(fields)
+	if err != nil {
+		return fmt.Errorf("failed to update record with ID %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/mockserver"
+)
+
+// mockBaseID is the Airtable base in the printed config if the fixtures don't
+// have Airtable records. The mock accepts any base, and its tables start empty.
+const mockBaseID = "appMockBase"
+
+var mockServerFlags struct {
+	addr     string
+	smtpAddr string
+	fixtures string
+}
+
+var mockServerCmd = &coral.Command{
+	Use:   "mock-server",
+	Short: "Serve mock APIs for local testing",
+	Long: `The mock-server command serves in-memory fakes of the APIs oats uses
+(Airtable, ScholarSphere, RMD, CrossRef, doi.org, DataCite, Unpaywall, and Open
+Access Button), seeded from JSON fixture files in the --fixtures directory (see
+mockserver/testdata/fixtures for examples). Changes, such as Airtable updates
+and deposits, are kept in memory until the server stops. It also runs an SMTP
+server on --smtp-addr that accepts and logs all messages, for commands that
+send email.
+
+To run other commands against the mock server, use a config file with the
+endpoints printed at startup. The config uses the first Airtable base in the
+fixtures, or "appMockBase", with empty tables, if there are no Airtable
+fixtures. A config file is not needed to run the server.`,
+	Annotations: map[string]string{ANNOT_NO_CONFIG: "true"},
+	Args:        coral.NoArgs,
+	RunE:        runMockServer,
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+	mockServerCmd.Flags().StringVarP(&mockServerFlags.addr, "addr", "", "localhost:8080", "address to listen on")
+	mockServerCmd.Flags().StringVarP(&mockServerFlags.smtpAddr, "smtp-addr", "", "localhost:2525", "address for the SMTP server (disabled if empty)")
+	mockServerCmd.Flags().StringVarP(&mockServerFlags.fixtures, "fixtures", "", "", "directory with fixture files")
+}
+
+func runMockServer(cmd *coral.Command, args []string) error {
+	// runs until interrupted
+	defaultInterrupts()
+	fx := &mockserver.Fixtures{}
+	if mockServerFlags.fixtures != "" {
+		var err error
+		fx, err = mockserver.LoadFixtures(mockServerFlags.fixtures)
+		if err != nil {
+			return fmt.Errorf("❌ failed to load fixtures: %w", err)
+		}
+	}
+	srv, err := mockserver.New(fx)
+	if err != nil {
+		return fmt.Errorf("❌ invalid fixtures: %w", err)
+	}
+	var bases []string
+	for b := range fx.Airtable {
+		bases = append(bases, b)
+	}
+	sort.Strings(bases)
+	base := mockBaseID
+	if len(bases) > 0 {
+		base = bases[0]
+	}
+	ends := mockserver.EndpointsFor("http://" + mockServerFlags.addr)
+	fmt.Fprintf(cmd.OutOrStdout(), mockConfig, ends.Airtable, base, base,
+		ends.Unpaywall, ends.OAButton, ends.ScholarSphere, ends.ScholarSphere,
+		ends.RMD, ends.RMD, ends.CrossRef, ends.DOI, ends.DataCite)
+	if mockServerFlags.smtpAddr != "" {
+		ln, err := net.Listen("tcp", mockServerFlags.smtpAddr)
+		if err != nil {
+			return fmt.Errorf("❌ failed to start SMTP server: %w", err)
+		}
+		defer ln.Close()
+		smtpSrv := &mockserver.SMTPServer{
+			OnMessage: func(msg mockserver.Message) { log.Printf("✅ received email: %s", msg) },
+		}
+		go smtpSrv.Serve(ln)
+		fmt.Fprintf(cmd.OutOrStdout(), mockEmailConfig, mockServerFlags.smtpAddr)
+		log.Printf("✅ serving SMTP on %s", mockServerFlags.smtpAddr)
+	} else {
+		fmt.Fprintln(cmd.OutOrStdout())
+	}
+	log.Printf("✅ serving mock APIs on %s (Airtable bases: %v)", mockServerFlags.addr, bases)
+	return http.ListenAndServe(mockServerFlags.addr, srv)
+}
+
+// mockConfig is the config file template for the mock server's endpoints
+const mockConfig = `## config.yml for the mock server
+airtable:
+  url: %q
+  apikey: "mock"
+  base:
+    production: %q
+    test: %q
+  tasks: "Tasks"
+  activity_insight: "Activity Insight"
+unpaywall:
+  url: %q
+  email: "oats@example.com"
+openaccessbutton:
+  url: %q
+  key: "mock"
+scholarsphere:
+  apikey: "mock"
+  production: %q
+  test: %q
+rmdb:
+  apikey: "mock"
+  production: %q
+  test: %q
+crossref:
+  url: %q
+doi:
+  url: %q
+datacite:
+  url: %q
+cache:
+  ttl: {crossref: "0", doi: "0", unpaywall: "0", oabutton: "0", rmd: "0"}
+`
+
+// mockEmailConfig is the config file template for the mock SMTP server
+const mockEmailConfig = `email:
+  from: "Open Access Team <oats@example.com>"
+  domain: "example.com"
+  smtp:
+    host: %q
+
+`
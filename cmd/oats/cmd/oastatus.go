@@ -11,7 +11,7 @@ import (
 	"log"
 
 	"github.com/muesli/coral"
-	"github.com/psu-libraries/oats/unpaywall"
+	"github.com/psu-libraries/oats/doi"
 )
 
 // Updates OA_status in Airtable using Unpaywall API
@@ -27,34 +27,41 @@ column will be set using the "best_oa_location" field from Unpaywall.`,
 }
 
 func init() {
+	addWorkersFlag(oastatusCmd)
 	rootCmd.AddCommand(oastatusCmd)
 }
 
 func runOAStatus(cmd *coral.Command, args []string) error {
+	ctx := cmd.Context()
 	// unpaywall client
-	unclient := unpaywall.NewClient(oats.Unpaywall.Email)
+	unclient := newUnpaywallClient()
 	// Query Airtable: filter confirmed and present DOIs
 	filter := fmt.Sprintf("AND(LEN({%s})>1,{%s},{%s} != \"Complete\")", COL_DOI, COL_DOI_CONF, COL_STATUS)
 	// return selected columss
 	cols := []string{COL_DOI, COL_DOI_CONF, COL_OA_LINK, COL_OA_STATUS}
-	recs, err := oats.GetRecordsFilterFields(oats.Airtable.Tasks, filter, cols)
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, cols)
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 	log.Printf("Found %d active tasks with confirmed DOIs.", len(recs))
-	for _, r := range recs {
-		doi, _ := r.Fields[COL_DOI].(string)
+	sum := newSummary(cmd.Name(), len(recs))
+	err = forEach(stopCtx, len(recs), workersFlag, func(i int) error {
+		r := recs[i]
+		airDOI, _ := r.Fields[COL_DOI].(string)
 		oaStatus, _ := r.Fields[COL_OA_STATUS].(string)
 		oaLink, _ := r.Fields[COL_OA_LINK].(string)
-		if doi == "" {
-			log.Print("❌ skipping record with missing DOI")
-			continue
+		taskDOI, err := doi.Parse(airDOI)
+		if err != nil {
+			log.Printf("❌ skipping record with missing DOI: %s", err)
+			sum.add(result(r.ID, "", "").failed(ERR_INPUT, err))
+			return nil
 		}
 		// record from Unpaywall
-		unInfo, err := unclient.GetDOI(doi)
+		unInfo, err := unclient.GetDOI(ctx, taskDOI)
 		if err != nil {
-			log.Printf("❌ %s, Unpaywall error: %s", doi, err.Error())
-			continue
+			log.Printf("❌ %s, Unpaywall error: %s", taskDOI, err.Error())
+			sum.add(result(r.ID, taskDOI, "").failed(ERR_API, err))
+			return nil
 		}
 		preferredOALink := unInfo.BestOALink.URLpage
 		// record should be updated
@@ -68,17 +75,20 @@ func runOAStatus(cmd *coral.Command, args []string) error {
 		if len(update) > 0 {
 			_, err := r.UpdateRecordPartial(update)
 			if err != nil {
-				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", doi, err)
+				sum.add(result(r.ID, taskDOI, "").failed(ERR_AIRTABLE, err))
+				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", taskDOI, err)
 			}
-			message := fmt.Sprintf("✅ %s:", doi)
+			message := fmt.Sprintf("✅ %s:", taskDOI)
 			for k, val := range update {
 				message += fmt.Sprintf(" %s=%s", k, val.(string))
 			}
 			log.Println(message)
+			sum.add(result(r.ID, taskDOI, OUTCOME_UPDATED).changed(update))
 		} else {
-			log.Printf("- no update: %s", doi)
+			log.Printf("- no update: %s", taskDOI)
+			sum.add(result(r.ID, taskDOI, OUTCOME_UNCHANGED))
 		}
-
-	}
-	return nil
+		return nil
+	})
+	return sum.finish(err)
 }
@@ -7,9 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/oabutton"
 )
 
@@ -40,42 +40,51 @@ Other columns that may be updated are:
 }
 
 func init() {
+	addWorkersFlag(permissionsCmd)
 	rootCmd.AddCommand(permissionsCmd)
 }
 
 func runPermissions(cmd *coral.Command, args []string) error {
-	oabc := oabutton.NewClient(oats.OpenAccessButton.Key)
+	ctx := cmd.Context()
+	oabc := newOABClient()
 
 	// Query Airtable: filter confirmed and present DOIs and no Permissions
 	filter := fmt.Sprintf("AND(LEN({%s})>1,{%s},NOT({%s}))", COL_DOI, COL_DOI_CONF, COL_PERM)
 	// return selected columns
-	recs, err := oats.GetRecordsFilterFields(oats.Airtable.Tasks, filter, []string{COL_DOI})
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, []string{COL_DOI})
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 	log.Printf("Found %d records with confirmed DOIs and no set permissions", len(recs))
-	for _, r := range recs {
-		doi, ok := r.Fields[COL_DOI].(string)
-		if !ok {
-			log.Print("❌ missing DOI")
-			continue
+	sum := newSummary(cmd.Name(), len(recs))
+	err = forEach(stopCtx, len(recs), workersFlag, func(i int) error {
+		r := recs[i]
+		airDOI, _ := r.Fields[COL_DOI].(string)
+		taskDOI, err := doi.Parse(airDOI)
+		if err != nil {
+			log.Printf("❌ missing DOI: %s", err)
+			sum.add(result(r.ID, "", "").failed(ERR_INPUT, err))
+			return nil
 		}
-		time.Sleep(500 * time.Millisecond)
-		perms, err := oabc.GetPermissions(doi)
+		perms, err := oabc.GetPermissions(ctx, taskDOI)
 		if err != nil && !errors.Is(err, oabutton.ErrNotArticle) {
-			log.Printf("❌ unexpected error from OAB Permissions API, %s: %s", doi, err.Error())
-			continue
+			log.Printf("❌ unexpected error from OAB Permissions API, %s: %s", taskDOI, err.Error())
+			sum.add(result(r.ID, taskDOI, "").failed(ERR_API, err))
+			return nil
 		}
 		if errors.Is(err, oabutton.ErrNotArticle) || len(perms) == 0 {
-			_, err := r.UpdateRecordPartial(map[string]interface{}{
+			update := map[string]interface{}{
 				COL_PERM:     PERM_NOTFOUND,
 				COL_PERM_SRC: PERMSRC,
-			})
+			}
+			_, err := r.UpdateRecordPartial(update)
 			if err != nil {
-				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", doi, err)
+				sum.add(result(r.ID, taskDOI, "").failed(ERR_AIRTABLE, err))
+				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", taskDOI, err)
 			}
-			log.Printf("❌ no policies found for %s (%s=%s)", doi, COL_PERM, PERM_NOTFOUND)
-			continue
+			log.Printf("❌ no policies found for %s (%s=%s)", taskDOI, COL_PERM, PERM_NOTFOUND)
+			sum.add(result(r.ID, taskDOI, OUTCOME_UPDATED).changed(update))
+			return nil
 		}
 		var perm oabutton.ArchiveConditions
 		for i := range perms {
@@ -85,31 +94,38 @@ func runPermissions(cmd *coral.Command, args []string) error {
 			}
 		}
 		if !perm.ScholarSphereOK() {
-			_, err := r.UpdateRecordPartial(map[string]interface{}{
+			update := map[string]interface{}{
 				COL_PERM:     PERM_CLOSED,
 				COL_PERM_SRC: PERMSRC,
-			})
+			}
+			_, err := r.UpdateRecordPartial(update)
 			if err != nil {
-				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", doi, err)
+				sum.add(result(r.ID, taskDOI, "").failed(ERR_AIRTABLE, err))
+				return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", taskDOI, err)
 			}
-			log.Printf("✅ updated %s (%s=%s)\n", doi, COL_PERM, PERM_CLOSED)
-			continue
+			log.Printf("✅ updated %s (%s=%s)\n", taskDOI, COL_PERM, PERM_CLOSED)
+			sum.add(result(r.ID, taskDOI, OUTCOME_UPDATED).changed(update))
+			return nil
 		}
 		license := perm.BestLicense()
 		if license == "" {
 			license = "other-closed"
 		}
-		_, err = r.UpdateRecordPartial(map[string]interface{}{
+		update := map[string]interface{}{
 			COL_EMBARGO:  perm.EmbargoEnd,
 			COL_STMNT:    perm.StatementGuess,
 			COL_LICENSE:  license,
 			COL_PERM:     PERM_OPEN,
 			COL_PERM_SRC: PERMSRC,
-		})
+		}
+		_, err = r.UpdateRecordPartial(update)
 		if err != nil {
-			return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", doi, err)
+			sum.add(result(r.ID, taskDOI, "").failed(ERR_AIRTABLE, err))
+			return fmt.Errorf("Stopped during doi=%s because of Airtable update error: %w", taskDOI, err)
 		}
-		log.Printf("✅ updated %s (%s=%s)\n", doi, COL_PERM, PERM_OPEN)
-	}
-	return nil
+		log.Printf("✅ updated %s (%s=%s)\n", taskDOI, COL_PERM, PERM_OPEN)
+		sum.add(result(r.ID, taskDOI, OUTCOME_UPDATED).changed(update))
+		return nil
+	})
+	return sum.finish(err)
 }
@@ -0,0 +1,382 @@
+package cmd
+
+// The report command prints workflow statistics from the Tasks and Activity
+// Insight tables: counts of Tasks by Status, Permissions, OA_status,
+// DOI_Confirmed, and RMD_Updated; deposits per month; the median time from
+// Task creation to deposit; and the share of open Tasks that are blocked on
+// permissions or files. Deposits are dated using the Deposit_Date column,
+// which is set by the deposit command. Open Tasks are Tasks that haven't been
+// deposited or completed. They are blocked on permissions if the Permissions
+// column is set to a value other than "Accepted Version OK", and blocked on
+// files if the Activity Insight record has no POST_FILE_1_DOC. The report is
+// rendered as a table, CSV, or a standalone HTML page.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/muesli/coral"
+)
+
+// report formats
+const (
+	REPORT_TABLE = "table"
+	REPORT_CSV   = "csv"
+	REPORT_HTML  = "html"
+)
+
+// value used in breakdowns for empty columns
+const reportNone = "(none)"
+
+var reportFlags struct {
+	format string
+}
+
+var reportCmd = &coral.Command{
+	Use:   "report",
+	Short: "Print workflow statistics for Tasks in Airtable",
+	Long: `The report command prints workflow statistics from the Tasks and Activity
+Insight tables: counts of Tasks by Status, Permissions, OA_status,
+DOI_Confirmed, and RMD_Updated; deposits per month; the median time from
+Task creation to deposit; and the share of open Tasks that are blocked on
+permissions or files. Deposits are dated using the Deposit_Date column,
+which is set by the deposit command. Open Tasks are Tasks that haven't been
+deposited or completed. They are blocked on permissions if the Permissions
+column is set to a value other than "Accepted Version OK", and blocked on
+files if the Activity Insight record has no POST_FILE_1_DOC. The report is
+rendered as a table, CSV, or a standalone HTML page (--format).`,
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVarP(&reportFlags.format, "format", "f", REPORT_TABLE, "report format: table, csv, or html")
+}
+
+func runReport(cmd *coral.Command, args []string) error {
+	if err := rejectOutput(cmd); err != nil {
+		return err
+	}
+	var render func(io.Writer, *workflowStats) error
+	switch reportFlags.format {
+	case REPORT_TABLE:
+		render = renderReportTable
+	case REPORT_CSV:
+		render = renderReportCSV
+	case REPORT_HTML:
+		render = renderReportHTML
+	default:
+		return fmt.Errorf("invalid --format %q: expected %s, %s, or %s", reportFlags.format, REPORT_TABLE, REPORT_CSV, REPORT_HTML)
+	}
+	ctx := cmd.Context()
+	cols := []string{COL_AI_ID, COL_STATUS, COL_PERM, COL_OA_STATUS, COL_DOI_CONF, COL_RMD_UPDATED, COL_SCHOLINK, COL_DEP_DATE}
+	tasks, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, "", cols)
+	if err != nil {
+		return fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	aiRecs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.ActivityInsight, "", []string{"Tasks", "POST_FILE_1_DOC"})
+	if err != nil {
+		return fmt.Errorf(`failed to get airtable records: %w`, err)
+	}
+	stats := computeWorkflowStats(tasks, aiRecs)
+	stats.Base = oats.AirtableBase()
+	stats.Generated = time.Now()
+	return render(os.Stdout, stats)
+}
+
+// reportCount is the number of Tasks with a value
+type reportCount struct {
+	Value string
+	Count int
+	Share float64 // percent of all Tasks
+}
+
+// reportBreakdown counts Tasks by the values of a column
+type reportBreakdown struct {
+	Column string
+	Counts []reportCount // by descending count
+}
+
+// workflowStats are the statistics in the report
+type workflowStats struct {
+	Base      string // Airtable base
+	Generated time.Time
+
+	Tasks      int
+	Breakdowns []reportBreakdown
+
+	NoTask          int           // Activity Insight entries without Tasks
+	Deposited       int           // Tasks with ScholarSphere links or Status=Deposited
+	UndatedDeposits int           // deposited Tasks without Deposit_Date
+	DepositsByMonth []reportCount // by month ("2006-01"); Share is of dated deposits
+	MedianDays      float64       // from Task creation to deposit
+	MedianCount     int           // deposits used for MedianDays
+
+	Open              int // Tasks that haven't been deposited or completed
+	BlockedPermission int
+	BlockedFile       int
+}
+
+// BlockedPermissionShare is the percent of open Tasks blocked on permissions
+func (s *workflowStats) BlockedPermissionShare() float64 {
+	return percent(s.BlockedPermission, s.Open)
+}
+
+// BlockedFileShare is the percent of open Tasks blocked on files
+func (s *workflowStats) BlockedFileShare() float64 {
+	return percent(s.BlockedFile, s.Open)
+}
+
+// rejectOutput returns an error if --output is set. Reports aren't record
+// results; their format is set with --format.
+func rejectOutput(cmd *coral.Command) error {
+	if cmd.Flags().Changed("output") {
+		return fmt.Errorf("%s doesn't support --output: use --format", cmd.Name())
+	}
+	return nil
+}
+
+// computeWorkflowStats computes the statistics for the Tasks and Activity
+// Insight records
+func computeWorkflowStats(tasks, aiRecs []*airtable.Record) *workflowStats {
+	stats := &workflowStats{Tasks: len(tasks)}
+	hasFile := make(map[string]bool) // by Activity Insight record ID
+	for _, ai := range aiRecs {
+		file, _ := ai.Fields["POST_FILE_1_DOC"].(string)
+		hasFile[ai.ID] = file != ""
+		if ids, _ := ai.Fields["Tasks"].([]interface{}); len(ids) == 0 {
+			stats.NoTask++
+		}
+	}
+	breakCols := []string{COL_STATUS, COL_PERM, COL_OA_STATUS, COL_DOI_CONF, COL_RMD_UPDATED}
+	counts := make([]map[string]int, len(breakCols))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	byMonth := make(map[string]int)
+	var days []float64
+	for _, t := range tasks {
+		for i, col := range breakCols {
+			counts[i][fieldValue(t, col)]++
+		}
+		status, _ := t.Fields[COL_STATUS].(string)
+		link, _ := t.Fields[COL_SCHOLINK].(string)
+		if link != "" || status == "Deposited" {
+			stats.Deposited++
+			depDate, _ := t.Fields[COL_DEP_DATE].(string)
+			dep, err := time.Parse("2006-01-02", depDate)
+			if err != nil {
+				stats.UndatedDeposits++
+				continue
+			}
+			byMonth[dep.Format("2006-01")]++
+			if created, err := time.Parse(time.RFC3339, t.CreatedTime); err == nil {
+				days = append(days, dep.Sub(created.Truncate(24*time.Hour)).Hours()/24)
+			}
+			continue
+		}
+		if status == "Complete" {
+			continue
+		}
+		stats.Open++
+		if perm, _ := t.Fields[COL_PERM].(string); perm != "" && perm != PERM_OPEN {
+			stats.BlockedPermission++
+		}
+		aiIDs, _ := t.Fields[COL_AI_ID].([]interface{})
+		if len(aiIDs) == 1 {
+			if id, _ := aiIDs[0].(string); !hasFile[id] {
+				stats.BlockedFile++
+			}
+		}
+	}
+	for i, col := range breakCols {
+		stats.Breakdowns = append(stats.Breakdowns, reportBreakdown{
+			Column: col,
+			Counts: sortedCounts(counts[i], len(tasks)),
+		})
+	}
+	var months []string
+	var dated int
+	for m, n := range byMonth {
+		months = append(months, m)
+		dated += n
+	}
+	sort.Strings(months)
+	for _, m := range months {
+		stats.DepositsByMonth = append(stats.DepositsByMonth, reportCount{
+			Value: m,
+			Count: byMonth[m],
+			Share: percent(byMonth[m], dated),
+		})
+	}
+	stats.MedianDays = median(days)
+	stats.MedianCount = len(days)
+	return stats
+}
+
+// fieldValue returns the record's value for the column as a string for
+// breakdowns
+func fieldValue(r *airtable.Record, col string) string {
+	switch v := r.Fields[col].(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		// unchecked checkboxes are missing
+		if col == COL_DOI_CONF || col == COL_RMD_UPDATED {
+			return "false"
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+	return reportNone
+}
+
+// sortedCounts returns the counts by descending count, then value
+func sortedCounts(counts map[string]int, total int) []reportCount {
+	var out []reportCount
+	for v, n := range counts {
+		out = append(out, reportCount{Value: v, Count: n, Share: percent(n, total)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// median returns the median of vals, or 0 if vals is empty
+func median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// renderReportTable writes the report as plain-text tables
+func renderReportTable(w io.Writer, s *workflowStats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Workflow report for %s (%s)\n\n", s.Base, s.Generated.Format("2006-01-02 15:04"))
+	fmt.Fprintf(tw, "Tasks\t%d\t\n", s.Tasks)
+	fmt.Fprintf(tw, "Activity Insight entries without Tasks\t%d\t\n", s.NoTask)
+	fmt.Fprintf(tw, "Deposited\t%d\t\n", s.Deposited)
+	fmt.Fprintf(tw, "Deposits without %s\t%d\t\n", COL_DEP_DATE, s.UndatedDeposits)
+	fmt.Fprintf(tw, "Median days from creation to deposit\t%.1f\t(%d deposits)\n", s.MedianDays, s.MedianCount)
+	fmt.Fprintf(tw, "Open\t%d\t\n", s.Open)
+	fmt.Fprintf(tw, "Open, blocked on permissions\t%d\t%.1f%%\n", s.BlockedPermission, s.BlockedPermissionShare())
+	fmt.Fprintf(tw, "Open, blocked on files\t%d\t%.1f%%\n", s.BlockedFile, s.BlockedFileShare())
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	tables := append(append([]reportBreakdown{}, s.Breakdowns...), reportBreakdown{Column: "Deposits per month", Counts: s.DepositsByMonth})
+	for _, b := range tables {
+		fmt.Fprintf(w, "\n%s\n", b.Column)
+		for _, c := range b.Counts {
+			fmt.Fprintf(tw, "  %s\t%d\t%.1f%%\n", c.Value, c.Count, c.Share)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderReportCSV writes the report as CSV with the columns section, value,
+// count, and share
+func renderReportCSV(w io.Writer, s *workflowStats) error {
+	cw := csv.NewWriter(w)
+	row := func(section, value string, count interface{}, share float64) {
+		cw.Write([]string{section, value, fmt.Sprint(count), strconv.FormatFloat(share, 'f', 1, 64)})
+	}
+	cw.Write([]string{"section", "value", "count", "share"})
+	row("summary", "Tasks", s.Tasks, 100)
+	row("summary", "Activity Insight entries without Tasks", s.NoTask, 0)
+	row("summary", "Deposited", s.Deposited, percent(s.Deposited, s.Tasks))
+	row("summary", "Deposits without "+COL_DEP_DATE, s.UndatedDeposits, percent(s.UndatedDeposits, s.Deposited))
+	row("summary", "Median days from creation to deposit", strconv.FormatFloat(s.MedianDays, 'f', 1, 64), 0)
+	row("summary", "Open", s.Open, percent(s.Open, s.Tasks))
+	row("summary", "Open, blocked on permissions", s.BlockedPermission, s.BlockedPermissionShare())
+	row("summary", "Open, blocked on files", s.BlockedFile, s.BlockedFileShare())
+	for _, b := range s.Breakdowns {
+		for _, c := range b.Counts {
+			row(b.Column, c.Value, c.Count, c.Share)
+		}
+	}
+	for _, c := range s.DepositsByMonth {
+		row("Deposits per month", c.Value, c.Count, c.Share)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderReportHTML writes the report as a standalone HTML page
+func renderReportHTML(w io.Writer, s *workflowStats) error {
+	return reportTmpl.Execute(w, s)
+}
+
+var reportTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>OA Workflow Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; text-align: left; }
+td.num { text-align: right; }
+.bar { background: #1e407c; height: 0.8em; }
+</style>
+</head>
+<body>
+<h1>OA Workflow Report</h1>
+<p>{{.Base}}, generated {{.Generated.Format "2006-01-02 15:04"}}</p>
+<h2>Summary</h2>
+<table>
+<tr><td>Tasks</td><td class="num">{{.Tasks}}</td><td></td></tr>
+<tr><td>Activity Insight entries without Tasks</td><td class="num">{{.NoTask}}</td><td></td></tr>
+<tr><td>Deposited</td><td class="num">{{.Deposited}}</td><td></td></tr>
+<tr><td>Deposits without Deposit_Date</td><td class="num">{{.UndatedDeposits}}</td><td></td></tr>
+<tr><td>Median days from creation to deposit</td><td class="num">{{printf "%.1f" .MedianDays}}</td><td>{{.MedianCount}} deposits</td></tr>
+<tr><td>Open</td><td class="num">{{.Open}}</td><td></td></tr>
+<tr><td>Open, blocked on permissions</td><td class="num">{{.BlockedPermission}}</td><td>{{printf "%.1f%%" .BlockedPermissionShare}}</td></tr>
+<tr><td>Open, blocked on files</td><td class="num">{{.BlockedFile}}</td><td>{{printf "%.1f%%" .BlockedFileShare}}</td></tr>
+</table>
+<h2>Deposits per month</h2>
+<table>
+{{range .DepositsByMonth}}<tr><td>{{.Value}}</td><td class="num">{{.Count}}</td><td style="width: 20em"><div class="bar" style="width: {{printf "%.1f" .Share}}%"></div></td></tr>
+{{else}}<tr><td>No dated deposits</td></tr>
+{{end}}</table>
+{{range .Breakdowns}}<h2>{{.Column}}</h2>
+<table>
+<tr><th>Value</th><th>Tasks</th><th>Share</th></tr>
+{{range .Counts}}<tr><td>{{.Value}}</td><td class="num">{{.Count}}</td><td class="num">{{printf "%.1f%%" .Share}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
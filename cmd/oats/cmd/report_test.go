@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/muesli/coral"
+)
+
+func testWorkflowStats() *workflowStats {
+	aiRecs := []*airtable.Record{
+		{ID: "ai1", Fields: map[string]interface{}{"Tasks": []interface{}{"t1"}, "POST_FILE_1_DOC": "a.pdf"}},
+		{ID: "ai2", Fields: map[string]interface{}{"Tasks": []interface{}{"t2"}}},
+		{ID: "ai3", Fields: map[string]interface{}{"Tasks": []interface{}{"t3"}, "POST_FILE_1_DOC": "c.pdf"}},
+		{ID: "ai4", Fields: map[string]interface{}{}},
+	}
+	tasks := []*airtable.Record{
+		{ID: "t1", CreatedTime: "2022-01-01T10:00:00.000Z", Fields: map[string]interface{}{
+			COL_AI_ID: []interface{}{"ai1"}, COL_STATUS: "Deposited", COL_DOI_CONF: true,
+			COL_PERM: PERM_OPEN, COL_SCHOLINK: "https://scholarsphere.psu.edu/resources/1", COL_DEP_DATE: "2022-01-11",
+		}},
+		{ID: "t2", CreatedTime: "2022-01-01T10:00:00.000Z", Fields: map[string]interface{}{
+			COL_AI_ID: []interface{}{"ai2"}, COL_STATUS: "In Progress", COL_PERM: PERM_CLOSED,
+		}},
+		{ID: "t3", CreatedTime: "2022-01-01T10:00:00.000Z", Fields: map[string]interface{}{
+			COL_AI_ID: []interface{}{"ai3"}, COL_STATUS: "Deposited", COL_DEP_DATE: "2022-02-01",
+		}},
+		{ID: "t4", Fields: map[string]interface{}{COL_STATUS: "Deposited"}},
+		{ID: "t5", Fields: map[string]interface{}{COL_STATUS: "Complete"}},
+	}
+	return computeWorkflowStats(tasks, aiRecs)
+}
+
+func TestWorkflowStats(t *testing.T) {
+	s := testWorkflowStats()
+	if s.Tasks != 5 || s.NoTask != 1 || s.Deposited != 3 || s.UndatedDeposits != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	// 10 and 31 days
+	if s.MedianDays != 20.5 || s.MedianCount != 2 {
+		t.Errorf("expected median of 20.5 days for 2 deposits, got %.1f for %d", s.MedianDays, s.MedianCount)
+	}
+	if len(s.DepositsByMonth) != 2 || s.DepositsByMonth[0].Value != "2022-01" || s.DepositsByMonth[1].Share != 50 {
+		t.Errorf("unexpected deposits per month: %+v", s.DepositsByMonth)
+	}
+	if s.Open != 1 || s.BlockedPermission != 1 || s.BlockedFile != 1 || s.BlockedFileShare() != 100 {
+		t.Errorf("unexpected open tasks: %+v", s)
+	}
+	status := s.Breakdowns[0]
+	if status.Column != COL_STATUS || status.Counts[0].Value != "Deposited" || status.Counts[0].Count != 3 || status.Counts[0].Share != 60 {
+		t.Errorf("unexpected Status breakdown: %+v", status)
+	}
+	conf := s.Breakdowns[3]
+	if conf.Column != COL_DOI_CONF || conf.Counts[0].Value != "false" || conf.Counts[0].Count != 4 {
+		t.Errorf("unexpected DOI_Confirmed breakdown: %+v", conf)
+	}
+	oa := s.Breakdowns[2]
+	if oa.Counts[0].Value != reportNone {
+		t.Errorf("unexpected OA_status breakdown: %+v", oa)
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	s := testWorkflowStats()
+	s.Base = "appTest"
+	s.Generated = time.Date(2022, 3, 1, 9, 0, 0, 0, time.UTC)
+	table := []struct {
+		render func(*bytes.Buffer, *workflowStats) error
+		expect []string
+	}{
+		{func(b *bytes.Buffer, s *workflowStats) error { return renderReportTable(b, s) },
+			[]string{"Workflow report for appTest", "Open, blocked on files", "Deposits per month\n  2022-01  1  50.0%"}},
+		{func(b *bytes.Buffer, s *workflowStats) error { return renderReportCSV(b, s) },
+			[]string{"section,value,count,share\n", "Status,Deposited,3,60.0\n", "Deposits per month,2022-02,1,50.0\n"}},
+		{func(b *bytes.Buffer, s *workflowStats) error { return renderReportHTML(b, s) },
+			[]string{"<!DOCTYPE html>", "<h2>Permissions</h2>", `style="width: 50.0%"`}},
+	}
+	for i, row := range table {
+		var buf bytes.Buffer
+		if err := row.render(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range row.expect {
+			if !strings.Contains(buf.String(), e) {
+				t.Errorf("format %d: expected %q in:\n%s", i, e, buf.String())
+			}
+		}
+	}
+}
+
+func TestRejectOutput(t *testing.T) {
+	for _, args := range [][]string{
+		{"report"},
+		{"report", "--output", "csv"},
+		{"-o", "json", "report"},
+	} {
+		root := &coral.Command{Use: "oats", SilenceErrors: true, SilenceUsage: true}
+		root.PersistentFlags().StringP("output", "o", OUTPUT_TEXT, "")
+		root.AddCommand(&coral.Command{Use: "report", RunE: func(cmd *coral.Command, args []string) error {
+			return rejectOutput(cmd)
+		}})
+		root.SetArgs(args)
+		err := root.Execute()
+		if set := len(args) > 1; (err != nil) != set {
+			t.Errorf("%v: expected error=%v, got %v", args, set, err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+// DOI review queue: when the dois command finds a candidate DOI whose
+// citation doesn't match the Task, the candidate is added to the DOI review
+// table in Airtable (airtable.doi_review in the config file) instead of being
+// logged and forgotten. The review dois command presents each pending
+// candidate to a staff member, who can accept it (confirming the DOI for the
+// Task) or reject it. Candidates with a decision are not queued or asked
+// about again.
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
+)
+
+// DOI review table columns
+const (
+	REV_TASK      = "Task" // link to Tasks
+	REV_DOI       = "DOI"
+	REV_SOURCE    = "Source"
+	REV_AIR_TITLE = "Airtable_Title"
+	REV_SRC_TITLE = "Source_Title"
+	REV_SCORE     = "Similarity"
+	REV_REASON    = "Match_Reason"
+	REV_DECISION  = "Decision"
+	REV_DATE      = "Decision_Date"
+)
+
+// review decisions
+const (
+	DECISION_ACCEPT = "Accepted"
+	DECISION_REJECT = "Rejected"
+)
+
+// doiReviews holds the decision for each queued Task/DOI pair ("" if
+// pending). It is safe for concurrent use. A nil *doiReviews means the review
+// table is not configured.
+type doiReviews struct {
+	mu        sync.Mutex
+	decisions map[string]string // by reviewKey
+}
+
+func reviewKey(taskID string, d doi.DOI) string {
+	return taskID + " " + d.String()
+}
+
+// loadDOIReviews returns all queued candidates from the review table. It
+// returns nil if the table is not configured.
+func loadDOIReviews(ctx context.Context) (*doiReviews, error) {
+	if oats.Airtable.DOIReview == "" {
+		return nil, nil
+	}
+	cols := []string{REV_TASK, REV_DOI, REV_DECISION}
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.DOIReview, "", cols)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get DOI review records: %w`, err)
+	}
+	reviews := &doiReviews{decisions: make(map[string]string)}
+	for _, rec := range recs {
+		taskID := linkedID(rec, REV_TASK)
+		revDOI, _ := rec.Fields[REV_DOI].(string)
+		d, err := doi.Parse(revDOI)
+		if taskID == "" || err != nil {
+			continue
+		}
+		reviews.decisions[reviewKey(taskID, d)], _ = rec.Fields[REV_DECISION].(string)
+	}
+	return reviews, nil
+}
+
+// decision returns the decision for the Task's candidate DOI and whether it
+// has been queued.
+func (revs *doiReviews) decision(taskID string, d doi.DOI) (string, bool) {
+	if revs == nil {
+		return "", false
+	}
+	revs.mu.Lock()
+	defer revs.mu.Unlock()
+	dec, ok := revs.decisions[reviewKey(taskID, d)]
+	return dec, ok
+}
+
+// skip returns the decision for the Task's candidate DOI and whether the dois
+// command should skip it: pending and rejected candidates are skipped.
+func (revs *doiReviews) skip(taskID string, d doi.DOI) (string, bool) {
+	dec, ok := revs.decision(taskID, d)
+	return dec, ok && dec != DECISION_ACCEPT
+}
+
+// queue adds the mismatch to the review table unless it is already there.
+// Mismatches without a candidate DOI can't be reviewed and are ignored.
+func (revs *doiReviews) queue(ctx context.Context, task *airtable.Record, e *TitleMatchErr) error {
+	if revs == nil || e.DOI == "" {
+		return nil
+	}
+	if _, ok := revs.decision(task.ID, e.DOI); ok {
+		return nil
+	}
+	fields := map[string]interface{}{
+		REV_TASK:      []interface{}{task.ID},
+		REV_DOI:       e.DOI.String(),
+		REV_SOURCE:    e.Source,
+		REV_AIR_TITLE: e.Expected,
+		REV_SRC_TITLE: e.Got,
+		REV_SCORE:     roundScore(e.Score),
+		REV_REASON:    e.Reason,
+	}
+	_, err := oats.PostRecords(ctx, oats.Airtable.DOIReview, []*airtable.Record{{Fields: fields}})
+	if err != nil {
+		return fmt.Errorf("failed to add DOI to review queue: %w", err)
+	}
+	revs.mu.Lock()
+	revs.decisions[reviewKey(task.ID, e.DOI)] = ""
+	revs.mu.Unlock()
+	log.Printf("📋 %s: added to review queue", e.DOI)
+	return nil
+}
+
+var reviewCmd = &coral.Command{
+	Use:   "review",
+	Short: "Interactively review queued candidates",
+}
+
+var reviewDOIsCmd = &coral.Command{
+	Use:   "dois",
+	Short: "Accept or reject DOIs in the review queue",
+	Long: `The review dois command presents each pending candidate in the DOI
+review table (airtable.doi_review in the config file) and asks whether to
+accept or reject it. Accepting a candidate confirms its DOI for the Task.
+Candidates that don't resolve, or whose Task has been confirmed with a
+different DOI since they were queued, aren't accepted and stay in the queue.
+Decisions are saved in the review table so the dois command doesn't queue the
+candidate again.`,
+	RunE: runReviewDOIs,
+}
+
+func init() {
+	reviewCmd.AddCommand(reviewDOIsCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
+
+func runReviewDOIs(cmd *coral.Command, args []string) error {
+	// interactive: interrupts quit immediately
+	defaultInterrupts()
+	ctx := cmd.Context()
+	if oats.Airtable.DOIReview == "" {
+		return fmt.Errorf("no DOI review table in config (airtable.doi_review)")
+	}
+	filter := fmt.Sprintf("{%s} = \"\"", REV_DECISION)
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.DOIReview, filter, nil)
+	if err != nil {
+		return fmt.Errorf(`failed to get DOI review records: %w`, err)
+	}
+	out := cmd.OutOrStdout()
+	in := bufio.NewReader(cmd.InOrStdin())
+	fmt.Fprintf(out, "%d DOIs to review\n", len(recs))
+	for i, rev := range recs {
+		revDOI, _ := rev.Fields[REV_DOI].(string)
+		source, _ := rev.Fields[REV_SOURCE].(string)
+		airTitle, _ := rev.Fields[REV_AIR_TITLE].(string)
+		srcTitle, _ := rev.Fields[REV_SRC_TITLE].(string)
+		score, _ := rev.Fields[REV_SCORE].(float64)
+		reason, _ := rev.Fields[REV_REASON].(string)
+		d, err := doi.Parse(revDOI)
+		if err != nil {
+			log.Printf("❌ skipping review record %s: %s", rev.ID, err)
+			continue
+		}
+		fmt.Fprintf(out, "\n[%d/%d] %s (source=%s, score=%.2f)\n", i+1, len(recs), d.URL(), source, score)
+		fmt.Fprintf(out, " - Airtable: %s\n", airTitle)
+		fmt.Fprintf(out, " - %s: %s\n", source, srcTitle)
+		if reason != "" {
+			fmt.Fprintf(out, " - Reasons: %s\n", reason)
+		}
+		ans, err := promptDecision(in, out)
+		if err != nil {
+			return err
+		}
+		switch ans {
+		case "q":
+			return nil
+		case "s":
+			continue
+		case "a":
+			err := acceptReview(ctx, rev, d, score)
+			if errors.Is(err, errNotAccepted) {
+				fmt.Fprintf(out, "⚠️ %s\n", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		case "r":
+			if err := decideReview(rev, DECISION_REJECT); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// promptDecision asks for a decision until it gets one of: a (accept), r
+// (reject), s (skip), or q (quit). End of input is treated as quit.
+func promptDecision(in *bufio.Reader, out io.Writer) (string, error) {
+	for {
+		fmt.Fprint(out, "[a]ccept, [r]eject, [s]kip, [q]uit? ")
+		line, err := in.ReadString('\n')
+		ans := strings.ToLower(strings.TrimSpace(line))
+		switch ans {
+		case "a", "accept", "r", "reject", "s", "skip", "q", "quit":
+			return ans[:1], nil
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return "q", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// errNotAccepted is returned by acceptReview if the candidate can't be
+// accepted. The review record is left undecided.
+var errNotAccepted = errors.New("not accepted")
+
+// acceptReview confirms the DOI for the review record's Task and records the
+// decision. The DOI isn't accepted if it doesn't resolve or if the Task has
+// been confirmed with a different DOI since the candidate was queued.
+func acceptReview(ctx context.Context, rev *airtable.Record, d doi.DOI, score float64) error {
+	taskID := linkedID(rev, REV_TASK)
+	if taskID == "" {
+		return fmt.Errorf("review record %s is not linked to a task", rev.ID)
+	}
+	task, err := oats.GetRecord(ctx, oats.Airtable.Tasks, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task for review record %s: %w", rev.ID, err)
+	}
+	if conf, _ := task.Fields[COL_DOI_CONF].(bool); conf {
+		taskDOI, _ := task.Fields[COL_DOI].(string)
+		if confDOI, _ := doi.Parse(taskDOI); confDOI != d {
+			return fmt.Errorf("%w: task already has a confirmed DOI: %s", errNotAccepted, taskDOI)
+		}
+	}
+	if res := doiClient.Resolve(ctx, d); !res.Resolves() {
+		return fmt.Errorf("%w: DOI does not resolve: %s", errNotAccepted, res.Reason())
+	}
+	cite, err := getCitation(ctx, d)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errNotAccepted, err)
+	}
+	match := workMatch{Score: score, Reasons: []string{"accepted in review"}}
+	if _, err := updateConfirmDOI(task, d, cite, match); err != nil {
+		return err
+	}
+	return decideReview(rev, DECISION_ACCEPT)
+}
+
+// decideReview saves the decision to the review table
+func decideReview(rev *airtable.Record, decision string) error {
+	update := map[string]interface{}{
+		REV_DECISION: decision,
+		REV_DATE:     time.Now().Format("2006-01-02"),
+	}
+	if _, err := rev.UpdateRecordPartial(update); err != nil {
+		return fmt.Errorf("failed to save review decision: %w", err)
+	}
+	return nil
+}
+
+// linkedID returns the ID of the single record linked in the column
+func linkedID(rec *airtable.Record, col string) string {
+	ids, _ := rec.Fields[col].([]interface{})
+	if len(ids) != 1 {
+		return ""
+	}
+	id, _ := ids[0].(string)
+	return id
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/cmd/oats/base"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/mockserver"
+)
+
+func TestPromptDecision(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"a\n", "a"},
+		{"Reject\n", "r"},
+		{"maybe\n\n  s  \n", "s"},
+		{"", "q"},
+		{"x\ny", "q"},
+		{"accept", "a"}, // no newline at end of input
+	}
+	for _, row := range table {
+		in := bufio.NewReader(strings.NewReader(row.input))
+		got, err := promptDecision(in, io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != row.expect {
+			t.Errorf("input %q: expected %q, got %q", row.input, row.expect, got)
+		}
+	}
+}
+
+const mockBase = "appTestBase"
+
+// newMockOats sets oats to use a mock Airtable server with the records and
+// returns the server. oats is restored when the test finishes.
+func newMockOats(t *testing.T, tables map[string][]mockserver.Record) *mockserver.Server {
+	t.Helper()
+	return newMockServices(t, &mockserver.Fixtures{
+		Airtable: map[string]map[string][]mockserver.Record{mockBase: tables},
+	})
+}
+
+// newMockServices sets oats and the shared API clients to use a mock server
+// with the fixtures and returns the server. Airtable fixtures should use
+// mockBase. oats and the clients are restored when the test finishes.
+func newMockServices(t *testing.T, fx *mockserver.Fixtures) *mockserver.Server {
+	t.Helper()
+	srv, err := mockserver.New(fx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	ends := mockserver.EndpointsFor(ts.URL)
+	conf := fmt.Sprintf(`airtable:
+  url: %q
+  apikey: "mock"
+  base:
+    test: %q
+  tasks: "Tasks"
+  activity_insight: "Activity Insight"
+  doi_review: "DOI Review"
+crossref:
+  url: %q
+doi:
+  url: %q
+datacite:
+  url: %q
+cache:
+  dir: %q
+`, ends.Airtable, mockBase, ends.CrossRef, ends.DOI, ends.DataCite, t.TempDir())
+	file := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(file, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prev, prevDOI, prevCrossref, prevDatacite := oats, doiClient, crossrefClient, dataciteClient
+	t.Cleanup(func() {
+		oats, doiClient, crossrefClient, dataciteClient = prev, prevDOI, prevCrossref, prevDatacite
+	})
+	oats, err = base.NewOats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initClients()
+	return srv
+}
+
+// reviewRecord returns a DOI review record for the task
+func reviewRecord(id, taskID, d, decision string) mockserver.Record {
+	fields := map[string]interface{}{REV_DOI: d}
+	if taskID != "" {
+		fields[REV_TASK] = []interface{}{taskID}
+	}
+	if decision != "" {
+		fields[REV_DECISION] = decision
+	}
+	return mockserver.Record{ID: id, Fields: fields}
+}
+
+func TestLoadDOIReviews(t *testing.T) {
+	newMockOats(t, map[string][]mockserver.Record{
+		"DOI Review": {
+			reviewRecord("recRev1", "recTask1", "10.1000/pending", ""),
+			reviewRecord("recRev2", "recTask1", "https://doi.org/10.1000/REJECTED", DECISION_REJECT),
+			reviewRecord("recRev3", "recTask2", "10.1000/accepted", DECISION_ACCEPT),
+			reviewRecord("recRev4", "recTask2", "not a doi", ""),
+			reviewRecord("recRev5", "", "10.1000/unlinked", ""),
+		},
+	})
+	reviews, err := loadDOIReviews(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := []struct {
+		task     string
+		doi      doi.DOI
+		decision string
+		queued   bool
+		skip     bool
+	}{
+		{"recTask1", "10.1000/pending", "", true, true},
+		{"recTask1", "10.1000/rejected", DECISION_REJECT, true, true},
+		{"recTask2", "10.1000/accepted", DECISION_ACCEPT, true, false},
+		{"recTask2", "10.1000/pending", "", false, false}, // different task
+		{"recTask1", "10.1000/new", "", false, false},
+	}
+	for _, row := range table {
+		dec, queued := reviews.decision(row.task, row.doi)
+		_, skip := reviews.skip(row.task, row.doi)
+		if dec != row.decision || queued != row.queued || skip != row.skip {
+			t.Errorf("%s %s: expected (%q, queued=%v, skip=%v), got (%q, queued=%v, skip=%v)",
+				row.task, row.doi, row.decision, row.queued, row.skip, dec, queued, skip)
+		}
+	}
+	if len(reviews.decisions) != 3 {
+		t.Errorf("expected 3 reviews, got %d", len(reviews.decisions))
+	}
+
+	// not configured
+	oats.Airtable.DOIReview = ""
+	reviews, err = loadDOIReviews(context.Background())
+	if err != nil || reviews != nil {
+		t.Errorf("expected nil reviews, got %v, %v", reviews, err)
+	}
+	if _, skip := reviews.skip("recTask1", "10.1000/pending"); skip {
+		t.Error("expected nil reviews not to skip")
+	}
+}
+
+func TestQueueReview(t *testing.T) {
+	ctx := context.Background()
+	srv := newMockOats(t, map[string][]mockserver.Record{
+		"Tasks":      {{ID: "recTask1", Fields: map[string]interface{}{COL_TITLE: "Soil Carbon"}}},
+		"DOI Review": {reviewRecord("recRev1", "recTask1", "10.1000/queued", "")},
+	})
+	reviews, err := loadDOIReviews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &airtable.Record{ID: "recTask1"}
+	mismatch := func(d doi.DOI) *TitleMatchErr {
+		return &TitleMatchErr{DOI: d, Source: "CrossRef", Expected: "Soil Carbon", Got: "Soil Nitrogen", Score: 0.456, Reason: "title"}
+	}
+	for _, d := range []doi.DOI{"10.1000/new", "10.1000/new", "10.1000/queued", ""} {
+		if err := reviews.queue(ctx, task, mismatch(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs := srv.Records(mockBase, "DOI Review")
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 review records, got %d: %v", len(recs), recs)
+	}
+	added := recs[1].Fields
+	if added[REV_DOI] != "10.1000/new" || added[REV_SRC_TITLE] != "Soil Nitrogen" || added[REV_SCORE] != 0.46 {
+		t.Errorf("unexpected review record: %v", added)
+	}
+	if ids, _ := added[REV_TASK].([]interface{}); len(ids) != 1 || ids[0] != "recTask1" {
+		t.Errorf("unexpected task link: %v", added[REV_TASK])
+	}
+	if _, skip := reviews.skip("recTask1", "10.1000/new"); !skip {
+		t.Error("expected queued candidate to be skipped")
+	}
+}
+
+func TestDecideReview(t *testing.T) {
+	ctx := context.Background()
+	srv := newMockServices(t, &mockserver.Fixtures{
+		Airtable: map[string]map[string][]mockserver.Record{mockBase: {
+			"Tasks": {
+				{ID: "recTask1", Fields: map[string]interface{}{COL_TITLE: "Soil Carbon", COL_DOI: "10.1000/old"}},
+				{ID: "recTask2", Fields: map[string]interface{}{COL_TITLE: "Soil Nitrogen", COL_DOI: "10.1000/confirmed", COL_DOI_CONF: true}},
+			},
+			"DOI Review": {
+				reviewRecord("recRev1", "recTask1", "10.1000/accept", ""),
+				reviewRecord("recRev2", "recTask1", "10.1000/reject", ""),
+				reviewRecord("recRev3", "", "10.1000/unlinked", ""),
+				reviewRecord("recRev4", "recTask2", "10.1000/accept", ""),
+				reviewRecord("recRev5", "recTask1", "10.1000/missing", ""),
+			},
+		}},
+		CrossRef: []json.RawMessage{
+			json.RawMessage(`{"DOI": "10.1000/accept", "title": ["Soil Carbon"], "published-online": {"date-parts": [[2021, 3, 4]]}}`),
+		},
+	})
+	revs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.DOIReview, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]*airtable.Record)
+	for _, r := range revs {
+		byID[r.ID] = r
+	}
+	if err := acceptReview(ctx, byID["recRev1"], "10.1000/accept", 0.7); err != nil {
+		t.Fatal(err)
+	}
+	if err := decideReview(byID["recRev2"], DECISION_REJECT); err != nil {
+		t.Fatal(err)
+	}
+	if err := acceptReview(ctx, byID["recRev3"], "10.1000/unlinked", 0.7); err == nil || errors.Is(err, errNotAccepted) {
+		t.Errorf("expected error for review record without a task, got %v", err)
+	}
+	// Task confirmed with a different DOI after the candidate was queued
+	if err := acceptReview(ctx, byID["recRev4"], "10.1000/accept", 0.7); !errors.Is(err, errNotAccepted) {
+		t.Errorf("expected candidate for confirmed task not to be accepted, got %v", err)
+	}
+	if err := acceptReview(ctx, byID["recRev5"], "10.1000/missing", 0.7); !errors.Is(err, errNotAccepted) {
+		t.Errorf("expected DOI that doesn't resolve not to be accepted, got %v", err)
+	}
+
+	tasks := srv.Records(mockBase, "Tasks")
+	task := tasks[0].Fields
+	if task[COL_DOI] != "10.1000/accept" || task[COL_DOI_CONF] != true || task[COL_DOI_SCORE] != 0.7 {
+		t.Errorf("unexpected task after accept: %v", task)
+	}
+	if task[COL_PUBDATE] != "2021-03-04" {
+		t.Errorf("expected publication date from citation, got %v", task[COL_PUBDATE])
+	}
+	if task := tasks[1].Fields; task[COL_DOI] != "10.1000/confirmed" || task[COL_DOI_SCORE] != nil {
+		t.Errorf("expected confirmed task to be unchanged, got %v", task)
+	}
+	today := time.Now().Format("2006-01-02")
+	for i, row := range []struct {
+		decision interface{}
+		date     interface{}
+	}{
+		{DECISION_ACCEPT, today},
+		{DECISION_REJECT, today},
+		{nil, nil},
+		{nil, nil},
+		{nil, nil},
+	} {
+		rec := srv.Records(mockBase, "DOI Review")[i]
+		if rec.Fields[REV_DECISION] != row.decision || rec.Fields[REV_DATE] != row.date {
+			t.Errorf("%s: expected %v on %v, got %v", rec.ID, row.decision, row.date, rec.Fields)
+		}
+	}
+}
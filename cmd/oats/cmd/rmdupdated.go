@@ -12,7 +12,6 @@ import (
 	"strings"
 
 	"github.com/muesli/coral"
-	"github.com/psu-libraries/oats/rmd"
 )
 
 const SSLinkPrefix = "https://scholarsphere.psu.edu/resources/"
@@ -29,43 +28,51 @@ true.`,
 }
 
 func init() {
+	addWorkersFlag(rmdUpdatedCmd)
 	rootCmd.AddCommand(rmdUpdatedCmd)
 }
 
 func runRMDUpdated(cmd *coral.Command, args []string) error {
+	ctx := cmd.Context()
 
 	// always use rmd production data
 	rmdbURL := oats.RMDB.Production
-	rmdbC := rmd.NewClient(rmdbURL, oats.RMDB.APIKey)
+	rmdbC := newRMDClient(rmdbURL)
 
 	// Query Airtable:
 	// return selected columns
 	cols := []string{COL_AI_ID, COL_SCHOLINK}
 	filter := fmt.Sprintf("NOT({%s})", COL_RMD_UPDATED)
-	recs, err := oats.GetRecordsFilterFields(oats.Airtable.Tasks, filter, cols)
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, cols)
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 	log.Printf("Found %d active tasks that aren't updated in RMD", len(recs))
-	allFound := 0
-	for _, task := range recs {
-
+	sum := newSummary(cmd.Name(), len(recs))
+	err = forEach(stopCtx, len(recs), workersFlag, func(i int) error {
+		task := recs[i]
 		intIDs, ok := task.Fields[COL_AI_ID].([]interface{})
 		if !ok || len(intIDs) != 1 {
-			return fmt.Errorf(`expected single task with ID %s`, task.ID)
+			err := fmt.Errorf(`expected single task with ID %s`, task.ID)
+			sum.add(result(task.ID, "", "").failed(ERR_INPUT, err))
+			return err
 		}
-		aiRec, err := oats.GetRecord(oats.Airtable.ActivityInsight, intIDs[0].(string))
+		aiRec, err := oats.GetRecord(ctx, oats.Airtable.ActivityInsight, intIDs[0].(string))
 		if err != nil {
+			sum.add(result(task.ID, "", "").failed(ERR_AIRTABLE, err))
 			return err
 		}
-		aiID := aiRec.Fields["ID"].(string)
+		aiID, _ := aiRec.Fields["ID"].(string)
 		if aiID == "" {
-			return fmt.Errorf(`could not get AI ID for %s`, task.ID)
+			err := fmt.Errorf(`could not get AI ID for %s`, task.ID)
+			sum.add(result(task.ID, "", "").failed(ERR_INPUT, err))
+			return err
 		}
 		airLink, _ := task.Fields[COL_SCHOLINK].(string)
 
-		pubs, err := rmdbC.PublicationsAI(aiID)
+		pubs, err := rmdbC.PublicationsAI(ctx, aiID)
 		if err != nil {
+			sum.add(result(task.ID, "", "").failed(ERR_API, err))
 			return err
 		}
 		rmdLink := ""
@@ -88,14 +95,16 @@ func runRMDUpdated(cmd *coral.Command, args []string) error {
 			}
 			_, err = task.UpdateRecordPartial(update)
 			if err != nil {
+				sum.add(result(task.ID, "", "").failed(ERR_AIRTABLE, err))
 				return fmt.Errorf("error: %w", err)
 			}
-			allFound++
+			sum.add(result(task.ID, "", OUTCOME_UPDATED).changed(update))
 		} else {
 			msg = fmt.Sprintf("❌ %s: no ScholarSphere link in RMD", aiID)
+			sum.add(result(task.ID, "", OUTCOME_UNCHANGED))
 		}
 		log.Println(msg)
-	}
-	log.Printf("Done: set %s for %d tasks\n", COL_RMD_UPDATED, allFound)
-	return nil
+		return nil
+	})
+	return sum.finish(err)
 }
@@ -3,8 +3,10 @@ package cmd
 // root represents the base "oats" command when called without any subcommands.
 
 import (
+	"errors"
 	"log"
 	"os"
+	"time"
 
 	"github.com/muesli/coral"
 	"github.com/psu-libraries/oats/cmd/oats/base"
@@ -19,6 +21,10 @@ const (
 	COL_STATUS      = "Status"
 	COL_DOI         = "DOI"
 	COL_DOI_CONF    = "DOI_Confirmed"
+	COL_DOI_SUGGEST = "DOI_Suggestion"
+	COL_DOI_SCORE   = "DOI_Score"
+	COL_DOI_REASON  = "DOI_Match_Reason"
+	COL_UPDATE_STAT = "Update_Status" // retracted, corrected, etc.
 	COL_OA_STATUS   = "OA_status"
 	COL_OA_LINK     = "OA_Link"
 	COL_PERM        = "Permissions"
@@ -33,11 +39,15 @@ const (
 	COL_USER        = "User"
 	COL_SCHOLINK    = "ScholarSphere_Link"
 	COL_RMD_UPDATED = "RMD_Updated"
+	COL_DEP_DATE    = "Deposit_Date"
 )
 
 var rootFlags struct {
 	configFile string
 	production bool
+	noCache    bool
+	timeout    time.Duration
+	output     string
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -46,13 +56,32 @@ var rootCmd = &coral.Command{
 	Short:        "OA Tools: a collection of programs for managing the OA workflow",
 	Long:         ``,
 	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *coral.Command, args []string) error {
+		if err := checkOutput(rootFlags.output); err != nil {
+			return err
+		}
+		if cmd.Annotations[ANNOT_NO_CONFIG] == "" {
+			initConfig()
+		}
+		return nil
+	},
 }
 
+// ANNOT_NO_CONFIG is set in the Annotations of commands that run without a
+// config file.
+const ANNOT_NO_CONFIG = "no-config"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Interrupts are handled as described in interrupt.go. The exit code is 2 if
+// the command finished but some records failed, and 1 for other errors.
 func Execute() {
-	coral.OnInitialize(initConfig)
-	err := rootCmd.Execute()
+	ctx, done := handleInterrupts()
+	err := rootCmd.ExecuteContext(ctx)
+	done()
+	if errors.Is(err, errRecordsFailed) {
+		os.Exit(2)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -62,6 +91,9 @@ func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().StringVarP(&rootFlags.configFile, "config", "c", "config.yml", "config file")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.production, "production", "p", false, "run in production mode")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.noCache, "no-cache", "", false, "don't use cached API responses")
+	rootCmd.PersistentFlags().DurationVarP(&rootFlags.timeout, "timeout", "", 30*time.Second, "timeout for each API request (except Airtable)")
+	rootCmd.PersistentFlags().StringVarP(&rootFlags.output, "output", "o", OUTPUT_TEXT, "format for record results: text, json, or csv")
 }
 
 func initConfig() {
@@ -71,4 +103,8 @@ func initConfig() {
 		log.Fatal(err)
 	}
 	oats.Production = rootFlags.production
+	if err := checkCacheConfig(); err != nil {
+		log.Fatal(err)
+	}
+	initClients()
 }
@@ -9,9 +9,10 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"strings"
 
+	"github.com/mehanizm/airtable"
 	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/scholargo"
 )
 
@@ -32,16 +33,14 @@ func init() {
 }
 
 func runSSLink(cmd *coral.Command, args []string) error {
+	ctx := cmd.Context()
 	server := oats.ScholarSphere.Test
 	if oats.Production {
 		server = oats.ScholarSphere.Production
 	}
 	// ScholarSphere Client
-	cli := scholargo.Client{
-		BaseURL: server,
-		Key:     oats.ScholarSphere.APIKey,
-	}
-	scholDOIs, err := cli.DOIs()
+	cli := newScholarClient(server)
+	scholDOIs, err := cli.DOIs(ctx)
 	if err != nil {
 		return err
 	}
@@ -51,40 +50,45 @@ func runSSLink(cmd *coral.Command, args []string) error {
 	filter := fmt.Sprintf("AND(LEN({%s})>1,{%s},LEN({ScholarSphere_Link})<4,{Status} != \"Complete\")", COL_DOI, COL_DOI_CONF)
 	// return selected columss
 	cols := []string{COL_AI_ID, COL_DOI, COL_SCHOLINK}
-	recs, err := oats.GetRecordsFilterFields(oats.Airtable.Tasks, filter, cols)
+	recs, err := oats.GetRecordsFilterFields(ctx, oats.Airtable.Tasks, filter, cols)
 	if err != nil {
 		return fmt.Errorf(`failed to get airtable records: %w`, err)
 	}
 
 	log.Printf("Found %d active tasks with DOI and no ScholarSphere Link", len(recs))
-	for _, r := range recs {
+	sum := newSummary(cmd.Name(), len(recs))
+	err = setSSLinks(recs, scholDOIs, sum)
+	return sum.finish(err)
+}
 
-		doi := r.Fields[COL_DOI].(string)
-		if doi == "" || doi == "-" {
-			continue
+// setSSLinks sets the ScholarSphere link for records with DOIs in scholDOIs.
+// It stops after the first interrupt.
+func setSSLinks(recs []*airtable.Record, scholDOIs scholargo.DOIMap, sum *runSummary) error {
+	for _, r := range recs {
+		if stopCtx.Err() != nil {
+			return errInterrupted
 		}
-
-		var scholID string
-		for d, ids := range scholDOIs {
-			if strings.EqualFold(d, "doi:"+doi) {
-				if len(ids) == 0 {
-					continue
-				}
-				scholID = ids[0]
-				break
-			}
+		airDOI, _ := r.Fields[COL_DOI].(string)
+		taskDOI, err := doi.Parse(airDOI)
+		if err != nil {
+			sum.add(result(r.ID, "", OUTCOME_SKIPPED))
+			continue
 		}
-		if scholID == "" {
+		ids := scholDOIs.Find(taskDOI)
+		if len(ids) == 0 {
+			sum.add(result(r.ID, taskDOI, OUTCOME_UNCHANGED))
 			continue
 		}
-		link := "https://scholarsphere.psu.edu/resources/" + scholID
+		link := "https://scholarsphere.psu.edu/resources/" + ids[0]
 		var update = make(map[string]interface{})
 		update["ScholarSphere_Link"] = link
-		_, err := r.UpdateRecordPartial(update)
+		_, err = r.UpdateRecordPartial(update)
 		if err != nil {
-			return fmt.Errorf(`failed to update task with DOI %s: %w`, doi, err)
+			sum.add(result(r.ID, taskDOI, "").failed(ERR_AIRTABLE, err))
+			return fmt.Errorf(`failed to update task with DOI %s: %w`, taskDOI, err)
 		}
-		log.Printf("✅ updated %s: %s", doi, link)
+		sum.add(result(r.ID, taskDOI, OUTCOME_UPDATED).changed(update))
+		log.Printf("✅ updated %s: %s", taskDOI, link)
 	}
 	return nil
 }
@@ -0,0 +1,260 @@
+package cmd
+
+// Commands that process records collect the result of each record: the
+// record's ID, its DOI, the action taken (the outcome), the Airtable fields
+// that were changed, and for failures, the error and its category. When the
+// command finishes, including when it is interrupted, a summary is logged
+// and, with --output json or csv, the results are written to stdout. Log
+// messages go to stderr, so stdout can be piped to other programs. Commands
+// return errRecordsFailed if any record failed (exit code 2).
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+// common record outcomes
+const (
+	OUTCOME_CREATED   = "created"
+	OUTCOME_UPDATED   = "updated"
+	OUTCOME_UNCHANGED = "unchanged"
+	OUTCOME_SKIPPED   = "skipped"
+	OUTCOME_FAILED    = "failed"
+)
+
+// error categories
+const (
+	ERR_INPUT    = "input"    // missing or invalid values in Airtable or input files
+	ERR_API      = "api"      // error response from an API (other than Airtable)
+	ERR_AIRTABLE = "airtable" // error reading or updating Airtable
+	ERR_TIMEOUT  = "timeout"  // request timed out (see --timeout)
+	ERR_CANCELED = "canceled" // request canceled by an interrupt
+)
+
+// output formats for --output
+const (
+	OUTPUT_TEXT = "text"
+	OUTPUT_JSON = "json"
+	OUTPUT_CSV  = "csv"
+)
+
+// errRecordsFailed is returned by commands that finished but failed to
+// process some records
+var errRecordsFailed = errors.New("some records failed")
+
+// recordResult is the result of processing a record
+type recordResult struct {
+	ID       string   `json:"id"` // Airtable record ID (Activity Insight ID for import and merge)
+	DOI      string   `json:"doi,omitempty"`
+	Action   string   `json:"action"`                   // outcome
+	Fields   []string `json:"fields_changed,omitempty"` // Airtable fields changed
+	Error    string   `json:"error,omitempty"`
+	Category string   `json:"error_category,omitempty"`
+}
+
+// result returns a result for the record with the given ID and DOI (which may
+// be empty)
+func result(id string, d doi.DOI, action string) recordResult {
+	return recordResult{ID: id, DOI: d.String(), Action: action}
+}
+
+// changed returns a copy of res with Fields set to the names in update
+func (res recordResult) changed(update map[string]interface{}) recordResult {
+	res.Fields = make([]string, 0, len(update))
+	for k := range update {
+		res.Fields = append(res.Fields, k)
+	}
+	sort.Strings(res.Fields)
+	return res
+}
+
+// failed returns a copy of res for a record that failed with err. The
+// category is used if err doesn't have one (see errCategory).
+func (res recordResult) failed(category string, err error) recordResult {
+	res.Action = OUTCOME_FAILED
+	res.Error = err.Error()
+	res.Category = errCategory(err, category)
+	return res
+}
+
+// categoryErr is an error with a category
+type categoryErr struct {
+	category string
+	err      error
+}
+
+func (e *categoryErr) Error() string { return e.err.Error() }
+func (e *categoryErr) Unwrap() error { return e.err }
+
+// withCategory returns err with the category, or nil if err is nil
+func withCategory(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categoryErr{category: category, err: err}
+}
+
+// errCategory returns the category for err: the category set with
+// withCategory, ERR_CANCELED or ERR_TIMEOUT for canceled requests, or def.
+func errCategory(err error, def string) string {
+	var catErr *categoryErr
+	var netErr net.Error
+	switch {
+	case errors.As(err, &catErr):
+		return catErr.category
+	case errors.Is(err, context.Canceled):
+		return ERR_CANCELED
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ERR_TIMEOUT
+	}
+	return def
+}
+
+// checkOutput returns an error if the --output flag is invalid
+func checkOutput(format string) error {
+	switch format {
+	case OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_CSV:
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q: expected %s, %s, or %s", format, OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_CSV)
+}
+
+// runSummary collects record results. It is safe for concurrent use.
+type runSummary struct {
+	mu      sync.Mutex
+	command string
+	total   int            // records to process
+	counts  map[string]int // by outcome
+	results []recordResult
+}
+
+func newSummary(command string, total int) *runSummary {
+	return &runSummary{command: command, total: total, counts: make(map[string]int)}
+}
+
+// add adds the result for a processed record
+func (s *runSummary) add(res recordResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[res.Action]++
+	s.results = append(s.results, res)
+}
+
+// String describes the counts, e.g. "processed 5 of 12 records: 2 failed,
+// 3 updated"
+func (s *runSummary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var done int
+	var outcomes []string
+	for o, n := range s.counts {
+		done += n
+		outcomes = append(outcomes, fmt.Sprintf("%d %s", n, o))
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		return strings.SplitN(outcomes[i], " ", 2)[1] < strings.SplitN(outcomes[j], " ", 2)[1]
+	})
+	msg := fmt.Sprintf("processed %d of %d records", done, s.total)
+	if len(outcomes) > 0 {
+		msg += ": " + strings.Join(outcomes, ", ")
+	}
+	return msg
+}
+
+// finish writes the results in the --output format, logs the summary, and
+// returns the command's error. err is the error that stopped the command, if
+// any; if err is nil and records failed, errRecordsFailed is returned.
+func (s *runSummary) finish(err error) error {
+	if werr := s.write(os.Stdout, rootFlags.output, err); werr != nil {
+		log.Printf("❌ failed to write results: %s", werr)
+	}
+	log.Printf("%s: %s", runStatusMessage(err), s)
+	s.mu.Lock()
+	failed := s.counts[OUTCOME_FAILED]
+	s.mu.Unlock()
+	if err == nil && failed > 0 {
+		return fmt.Errorf("%w: %d", errRecordsFailed, failed)
+	}
+	return err
+}
+
+// runStatus describes how the command ended: "done", "interrupted", or
+// "stopped" (by err)
+func runStatus(err error) string {
+	switch {
+	case err == nil:
+		return "done"
+	case errors.Is(err, errInterrupted):
+		return "interrupted"
+	}
+	return "stopped"
+}
+
+// runStatusMessage is the prefix for the logged summary
+func runStatusMessage(err error) string {
+	switch runStatus(err) {
+	case "done":
+		return "Done"
+	case "interrupted":
+		return "⚠️ Interrupted"
+	}
+	return "❌ Stopped"
+}
+
+// runResults is the JSON output of a command
+type runResults struct {
+	Command string         `json:"command"`
+	Status  string         `json:"status"`          // see runStatus
+	Error   string         `json:"error,omitempty"` // error that stopped the command
+	Total   int            `json:"total"`           // records to process
+	Counts  map[string]int `json:"counts"`          // by outcome
+	Records []recordResult `json:"records"`
+}
+
+// write writes the results to w in the given format. Nothing is written for
+// OUTPUT_TEXT; the summary is logged.
+func (s *runSummary) write(w io.Writer, format string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch format {
+	case OUTPUT_JSON:
+		out := runResults{
+			Command: s.command,
+			Status:  runStatus(err),
+			Total:   s.total,
+			Counts:  s.counts,
+			Records: s.results,
+		}
+		if err != nil {
+			out.Error = err.Error()
+		}
+		if out.Records == nil {
+			out.Records = []recordResult{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case OUTPUT_CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "doi", "action", "fields_changed", "error_category", "error"})
+		for _, r := range s.results {
+			cw.Write([]string{r.ID, r.DOI, r.Action, strings.Join(r.Fields, ";"), r.Category, r.Error})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return nil
+}
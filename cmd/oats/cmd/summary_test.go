@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testSummary() *runSummary {
+	sum := newSummary("oastatus", 3)
+	update := map[string]interface{}{COL_OA_STATUS: "gold", COL_OA_LINK: "https://example.com"}
+	sum.add(result("rec1", "10.1000/a", OUTCOME_UPDATED).changed(update))
+	sum.add(result("rec2", "10.1000/b", "").failed(ERR_API, errors.New("bad, gateway")))
+	return sum
+}
+
+func TestSummaryJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSummary().write(&buf, OUTPUT_JSON, errInterrupted); err != nil {
+		t.Fatal(err)
+	}
+	var out runResults
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Command != "oastatus" || out.Status != "interrupted" || out.Total != 3 {
+		t.Errorf("unexpected output: %+v", out)
+	}
+	if out.Counts[OUTCOME_UPDATED] != 1 || out.Counts[OUTCOME_FAILED] != 1 || len(out.Records) != 2 {
+		t.Errorf("unexpected counts: %v", out.Counts)
+	}
+	if f := out.Records[0].Fields; len(f) != 2 || f[0] != COL_OA_LINK {
+		t.Errorf("unexpected fields changed: %v", f)
+	}
+	if r := out.Records[1]; r.Action != OUTCOME_FAILED || r.Category != ERR_API {
+		t.Errorf("unexpected failed record: %+v", r)
+	}
+}
+
+func TestSummaryCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSummary().write(&buf, OUTPUT_CSV, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := `id,doi,action,fields_changed,error_category,error
+rec1,10.1000/a,updated,OA_Link;OA_status,,
+rec2,10.1000/b,failed,,api,"bad, gateway"
+`
+	if got := buf.String(); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+	buf.Reset()
+	if err := testSummary().write(&buf, OUTPUT_TEXT, nil); err != nil || buf.Len() > 0 {
+		t.Errorf("expected no text output, got %q", buf.String())
+	}
+}
+
+func TestSummaryFinish(t *testing.T) {
+	err := testSummary().finish(nil)
+	if !errors.Is(err, errRecordsFailed) {
+		t.Errorf("expected errRecordsFailed, got %v", err)
+	}
+	sum := newSummary("sslink", 1)
+	sum.add(result("rec1", "", OUTCOME_UNCHANGED))
+	if err := sum.finish(nil); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	errStop := errors.New("stop")
+	if err := testSummary().finish(errStop); err != errStop {
+		t.Errorf("expected errStop, got %v", err)
+	}
+	if !strings.HasPrefix(testSummary().String(), "processed 2 of 3 records: 1 failed, 1 updated") {
+		t.Errorf("unexpected summary: %s", testSummary())
+	}
+}
+
+func TestErrCategory(t *testing.T) {
+	table := []struct {
+		err    error
+		expect string
+	}{
+		{errors.New("other"), ERR_INPUT},
+		{withCategory(ERR_AIRTABLE, errors.New("update failed")), ERR_AIRTABLE},
+		{fmt.Errorf("wrapped: %w", withCategory(ERR_API, errors.New("404"))), ERR_API},
+		{fmt.Errorf("request: %w", context.Canceled), ERR_CANCELED},
+		{fmt.Errorf("request: %w", context.DeadlineExceeded), ERR_TIMEOUT},
+	}
+	for _, row := range table {
+		if got := errCategory(row.err, ERR_INPUT); got != row.expect {
+			t.Errorf("%v: expected %q, got %q", row.err, row.expect, got)
+		}
+	}
+	if withCategory(ERR_API, nil) != nil {
+		t.Error("expected nil")
+	}
+}
@@ -4,12 +4,14 @@ package cmd
 // that do not have a corresponding Task.
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 
 	"github.com/mehanizm/airtable"
 	"github.com/muesli/coral"
+	"github.com/psu-libraries/oats/doi"
 )
 
 var tasksCmd = &coral.Command{
@@ -32,7 +34,7 @@ func init() {
 
 func runTasks(cmd *coral.Command, args []string) error {
 	// all the records in the Activity Insight table without a corresponding Task
-	needTasks, err := oats.GetRecordsFilterFields(oats.Airtable.ActivityInsight, `{Tasks} = ''`, nil)
+	needTasks, err := oats.GetRecordsFilterFields(cmd.Context(), oats.Airtable.ActivityInsight, `{Tasks} = ''`, nil)
 	if err != nil {
 		return fmt.Errorf(`failed to retrieve IDS from %s Airtable: %w`, oats.Airtable.ActivityInsight, err)
 	}
@@ -41,13 +43,17 @@ func runTasks(cmd *coral.Command, args []string) error {
 	for i, ai := range needTasks {
 		newTasks[i] = newTask(ai)
 	}
-	created, err := oats.PostRecords(oats.Airtable.Tasks, newTasks)
-	if err != nil {
-		return fmt.Errorf("failed to create tasks. Airtable Error Response: %w", err)
+	// PostRecords stops between batches after the first interrupt
+	created, err := oats.PostRecords(stopCtx, oats.Airtable.Tasks, newTasks)
+	sum := newSummary(cmd.Name(), len(newTasks))
+	for _, t := range created {
+		d, _ := t.Fields[COL_DOI].(string)
+		sum.add(result(t.ID, doi.DOI(d), OUTCOME_CREATED).changed(t.Fields))
 	}
-
-	fmt.Printf("%d new tasks created\n", len(created))
-	return nil
+	if err = stopErr(err); err != nil && !errors.Is(err, errInterrupted) {
+		err = fmt.Errorf("failed to create tasks. Airtable Error Response: %w", err)
+	}
+	return sum.finish(err)
 }
 
 // returns a corresponding task for the AI record
@@ -56,8 +62,9 @@ func newTask(ai *airtable.Record) *airtable.Record {
 	// copy some fields from Activity Insight Record to the Task
 	fields[COL_AI_ID] = []interface{}{ai.ID}
 	fields[COL_VERSION] = ai.Fields["Version"]
-	if doi, ok := ai.Fields["DOI"].(string); ok {
-		fields[COL_DOI] = cleanDOI(doi)
+	if aiDOI, ok := ai.Fields["DOI"].(string); ok {
+		d, _ := doi.Parse(aiDOI) // empty if invalid
+		fields[COL_DOI] = d.String()
 	}
 	fields[COL_TITLE] = ai.Fields["TITLE"]
 	fields[COL_JOURNAL] = ai.Fields["JOURNAL_NAME"]
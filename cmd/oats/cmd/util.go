@@ -3,40 +3,71 @@ package cmd
 // Some utility functions used by several commands
 
 import (
-	"net/http"
-	"net/url"
-	"regexp"
-	"strings"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mehanizm/airtable"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
 )
 
-var doiRE *regexp.Regexp = regexp.MustCompile(`10.\d{4,9}\/[-._;()\/:a-z0-9]+`)
+// getCitation returns metadata for the DOI from its registration agency:
+// the CrossRef or DataCite API, or doi.org content negotiation for other
+// agencies (e.g., mEDRA and JaLC).
+func getCitation(ctx context.Context, d doi.DOI) (*crossref.Citation, error) {
+	if cite, ok := crossrefClient.Cached(d); ok {
+		return cite, nil
+	}
+	ra, err := doiClient.RA(ctx, d)
+	if err != nil {
+		return nil, fmt.Errorf("registration agency lookup failed: %w", err)
+	}
+	switch ra {
+	case doi.RACrossref:
+		return crossrefClient.Work(ctx, d)
+	case doi.RADataCite:
+		return dataciteClient.GetCitation(ctx, d)
+	default:
+		return crossrefClient.CSL(ctx, d, ra)
+	}
+}
 
-func cleanDOI(old string) string {
-	doiRE.Longest()
-	escaped, err := url.QueryUnescape(old)
-	if err == nil {
-		old = escaped
+// updateStatusValue returns the Update_Status value for the citation, e.g.
+// "Retracted: https://doi.org/10.1000/notice", or "" if the work has not been
+// retracted, corrected, etc.
+func updateStatusValue(cite *crossref.Citation) string {
+	if cite == nil {
+		return ""
+	}
+	status, update := cite.UpdateStatus()
+	if status == "" {
+		return ""
+	}
+	if d, err := doi.Parse(update.DOI); err == nil {
+		return status + ": " + d.URL()
 	}
-	return doiRE.FindString(strings.ToLower(old))
+	return status
 }
 
-func resolvableDOI(doi string) bool {
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-	doi = cleanDOI(doi)
-	if doi == "" {
-		return false
-	}
-	url := "https://doi.org/" + doi
-	rsp, err := client.Get(url)
-	if err != nil {
-		return false
+// recordUpdateStatus saves the citation's update status to the Task if it
+// has changed. Retractions and corrections are logged.
+func recordUpdateStatus(task *airtable.Record, cite *crossref.Citation) error {
+	val := updateStatusValue(cite)
+	if val != "" {
+		icon := "⚠️"
+		if cite.Retracted() {
+			icon = "⛔"
+		}
+		log.Printf("%s %s: %s", icon, cite.DOI, val)
+	}
+	if old, _ := task.Fields[COL_UPDATE_STAT].(string); old == val {
+		return nil
 	}
-	if rsp.StatusCode != 302 {
-		return false
+	update := map[string]interface{}{COL_UPDATE_STAT: val}
+	if _, err := task.UpdateRecordPartial(update); err != nil {
+		return fmt.Errorf("failed to save update status: %w", err)
 	}
-	return true
+	return nil
 }
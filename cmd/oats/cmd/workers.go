@@ -0,0 +1,75 @@
+package cmd
+
+// Commands that update records one at a time (dois, oastatus, permissions,
+// rmdupdated) process records concurrently using forEach. Requests to each
+// API are limited by the shared clients (see clients.go), so the number of
+// workers doesn't change the request rates.
+
+import (
+	"context"
+	"sync"
+
+	"github.com/muesli/coral"
+)
+
+// default number of workers
+const defaultWorkers = 4
+
+// workersFlag is the value of the --workers flag
+var workersFlag int
+
+// addWorkersFlag adds the --workers flag to the command
+func addWorkersFlag(cmd *coral.Command) {
+	cmd.Flags().IntVarP(&workersFlag, "workers", "", defaultWorkers, "number of records to process concurrently")
+}
+
+// forEach calls fn for 0 to n-1 using up to the given number of concurrent
+// workers. After fn returns an error or the context is done, no new calls are
+// started; forEach waits for calls in progress and returns the first error,
+// or errInterrupted if the context was done before all calls were started.
+func forEach(ctx context.Context, n, workers int, fn func(i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		next     int
+	)
+	// take returns the next index or false if workers should stop
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr != nil || next >= n {
+			return 0, false
+		}
+		if ctx.Err() != nil {
+			firstErr = errInterrupted
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i, ok := take()
+				if !ok {
+					return
+				}
+				if err := fn(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
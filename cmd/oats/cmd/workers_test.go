@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var running, maxRunning int64
+	err := forEach(context.Background(), 20, 3, func(i int) error {
+		n := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		mu.Lock()
+		defer mu.Unlock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		seen[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 20 {
+		t.Errorf("expected 20 calls, got %d", len(seen))
+	}
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+}
+
+func TestForEachError(t *testing.T) {
+	errStop := errors.New("stop")
+	var calls int64
+	err := forEach(context.Background(), 100, 1, func(i int) error {
+		atomic.AddInt64(&calls, 1)
+		if i == 4 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected errStop, got %v", err)
+	}
+	if calls != 5 {
+		t.Errorf("expected 5 calls, got %d", calls)
+	}
+}
+
+func TestForEachInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int64
+	err := forEach(ctx, 100, 2, func(i int) error {
+		if atomic.AddInt64(&calls, 1) == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("expected errInterrupted, got %v", err)
+	}
+	if calls < 10 || calls > 11 {
+		t.Errorf("expected calls in progress to finish and no more to start, got %d calls", calls)
+	}
+}
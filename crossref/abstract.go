@@ -0,0 +1,150 @@
+package crossref
+
+import (
+	"encoding/xml"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// PlainAbstract returns the citation's abstract converted to plain text
+func (c Citation) PlainAbstract() string {
+	return AbstractText(c.Abstract)
+}
+
+// AbstractText converts an abstract with JATS (or HTML) markup to plain text.
+// Paragraphs, section titles, and list items are separated by blank lines.
+// Inline markup is removed, entities are decoded, MathML is replaced with its
+// alttext (or its text content), and the "Abstract" heading is dropped.
+func AbstractText(jats string) string {
+	if strings.TrimSpace(jats) == "" {
+		return ""
+	}
+	text, err := convertJATS(jats)
+	if err != nil {
+		// not well-formed: remove anything that looks like a tag
+		text = html.UnescapeString(tagRE.ReplaceAllString(jats, " "))
+	}
+	var paras []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+var tagRE = regexp.MustCompile(`<[^>]*>`)
+
+// elements that start a new paragraph
+var jatsBlocks = map[string]bool{
+	"p":            true,
+	"sec":          true,
+	"title":        true,
+	"list":         true,
+	"list-item":    true,
+	"disp-formula": true,
+	"def-list":     true,
+	"statement":    true,
+	"div":          true,
+	"br":           true,
+	"li":           true,
+	"h1":           true,
+	"h2":           true,
+	"h3":           true,
+	"h4":           true,
+}
+
+// convertJATS walks the markup and returns text with paragraphs separated by
+// "\n\n".
+func convertJATS(jats string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader("<abstract>" + jats + "</abstract>"))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	var (
+		out     strings.Builder
+		title   strings.Builder // text of the current title element
+		inTitle bool
+		skip    int // depth inside elements being skipped (e.g. MathML with alttext)
+	)
+	write := func(s string) {
+		if inTitle {
+			title.WriteString(s)
+			return
+		}
+		out.WriteString(s)
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			name := strings.ToLower(t.Name.Local)
+			if jatsBlocks[name] {
+				write("\n\n")
+			}
+			switch name {
+			case "title":
+				inTitle = true
+				title.Reset()
+			case "list-item", "li":
+				write("- ")
+			case "math", "inline-formula":
+				if alt := attr(t, "alttext"); alt != "" {
+					write(" " + alt + " ")
+					skip = 1
+				} else {
+					write(" ")
+				}
+			case "tex-math", "annotation":
+				// duplicates of the MathML presentation
+				skip = 1
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			name := strings.ToLower(t.Name.Local)
+			if name == "title" && inTitle {
+				inTitle = false
+				heading := strings.TrimSpace(title.String())
+				if !strings.EqualFold(strings.TrimRight(heading, ":."), "abstract") {
+					out.WriteString(heading)
+				}
+			}
+			if name == "math" || name == "inline-formula" {
+				write(" ")
+			}
+			if jatsBlocks[name] {
+				write("\n\n")
+			}
+		case xml.CharData:
+			if skip == 0 {
+				write(string(t))
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
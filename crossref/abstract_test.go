@@ -0,0 +1,26 @@
+package crossref_test
+
+import (
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+func TestAbstractText(t *testing.T) {
+	table := map[string]string{
+		``: ``,
+		`<jats:title>Abstract</jats:title><jats:p>First  paragraph with <jats:italic>italics</jats:italic>.</jats:p>
+		<jats:p>Second &amp; last &lt;p&gt; &#8211; done.</jats:p>`: "First paragraph with italics.\n\nSecond & last <p> – done.",
+		`<jats:sec><jats:title>Background</jats:title><jats:p>Text</jats:p></jats:sec>`:                                                                                                  "Background\n\nText",
+		`<jats:p>Mass of <mml:math xmlns:mml="http://www.w3.org/1998/Math/MathML" alttext="M_\odot"><mml:msub><mml:mi>M</mml:mi><mml:mo>⊙</mml:mo></mml:msub></mml:math> stars</jats:p>`: `Mass of M_\odot stars`,
+		`<jats:p>CO<jats:sub>2</jats:sub> levels<mml:math><mml:mi>x</mml:mi><mml:mo>=</mml:mo><mml:mn>1</mml:mn></mml:math></jats:p>`:                                                    `CO2 levels x=1`,
+		`<p>An HTML abstract&nbsp;with<br>a break</p>`:  "An HTML abstract with\n\na break",
+		`Plain text abstract.`:                          `Plain text abstract.`,
+		`<jats:p>Unclosed <jats:italic>markup</jats:p>`: `Unclosed markup`,
+	}
+	for in, expect := range table {
+		if out := crossref.AbstractText(in); out != expect {
+			t.Errorf("for %s\nexpected: %q\ngot:      %q", in, expect, out)
+		}
+	}
+}
@@ -1,30 +1,57 @@
 package crossref
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"context"
+	"github.com/psu-libraries/oats/doi"
 )
 
 // Citation data
 type Citation struct {
 	Title          []string `json:"title"`
+	Subtitle       []string `json:"subtitle"`
 	Abstract       string   `json:"abstract"`
 	Author         []Author `json:"author"`
 	ContainerTitle []string `json:"container-title"`
-	Publisher      string   `json:"publisher"`
-	Subject        []string `json:"subject"`
-	Language       string   `json:"language"`
-	Type           string   `json:"type"`
-	ISSN           []string
-	Source         string `json:"source"` // eg CrossRef
-	Issued         struct {
-		DateParts []DateParts `json:"date-parts"`
-	} `json:"issued"`
+	// abbreviated journal titles
+	ShortContainerTitle []string `json:"short-container-title"`
+	Publisher           string   `json:"publisher"`
+	Subject             []string `json:"subject"`
+	Language            string   `json:"language"`
+	Type                string   `json:"type"`
+	ISSN                []string
+	Source              string `json:"source"` // eg CrossRef
+	// see PublicationDate
+	Issued          Date      `json:"issued"`
+	PublishedPrint  Date      `json:"published-print"`
+	PublishedOnline Date      `json:"published-online"`
+	Created         Date      `json:"created"`
+	Volume          string    `json:"volume"`
+	Issue           string    `json:"issue"`
+	Page            string    `json:"page"`
+	Score           float64   `json:"score"` // relevance score for search results
+	DOI             string    `json:"DOI"`
+	URL             string    `json:"URL"` // landing page or DOI link
+	Funder          []Funder  `json:"funder"`
+	License         []License `json:"license"`
+	// notices (retractions, corrections, etc.) about this work
+	UpdatedBy []Update `json:"updated-by"`
+	// works this notice updates, if it is a notice
+	UpdateTo []Update `json:"update-to"`
 }
 
-// DateParts [y,m,d]
-type DateParts [3]int
+// Funder of the work
+type Funder struct {
+	Name  string   `json:"name"`
+	DOI   string   `json:"DOI"` // Open Funder Registry DOI
+	Award []string `json:"award"`
+}
+
+// License for a version of the work
+type License struct {
+	URL            string `json:"URL"`
+	ContentVersion string `json:"content-version"` // vor, am, tdm, or unspecified
+	DelayInDays    int    `json:"delay-in-days"`
+}
 
 // Author field in the citation
 type Author struct {
@@ -44,27 +71,8 @@ func (c Citation) AuthorStrings(f func(a *Author) string) []string {
 	return ret
 }
 
-func GetCitation(doi string) (*Citation, error) {
-	const (
-		serviceURL = `https://api.crossref.org/works/`
-	)
-	req, err := http.NewRequest(`GET`, serviceURL+doi, nil)
-	req.Header.Add("Accept", "application/json")
-	if err != nil {
-		return nil, err
-	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Server responded with status: %d", resp.StatusCode)
-	}
-	//{"status":"ok","message-type":"work","message-version":"1.0.0","message": {...}
-	var body struct {
-		Status  string
-		Message *Citation
-	}
-	return body.Message, json.NewDecoder(resp.Body).Decode(&body)
+// GetCitation returns the CrossRef citation for the DOI using the
+// DefaultClient
+func GetCitation(d doi.DOI) (*Citation, error) {
+	return DefaultClient.Work(context.Background(), d)
 }
@@ -0,0 +1,236 @@
+package crossref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/ratelimit"
+)
+
+const (
+	// DefaultBaseURL is the CrossRef REST API
+	DefaultBaseURL = `https://api.crossref.org`
+	// DefaultResolverURL is used for DOI content negotiation
+	DefaultResolverURL = `https://doi.org`
+	// userAgent identifies oats to CrossRef
+	userAgent = `oats (https://github.com/psu-libraries/oats)`
+	// maximum DOIs in a filter query
+	batchSize = 50
+)
+
+// Options for NewClient
+type Options struct {
+	BaseURL     string        // default: DefaultBaseURL
+	ResolverURL string        // for content negotiation (default: DefaultResolverURL)
+	Mailto      string        // contact email, for CrossRef's "polite" pool
+	HTTPClient  *http.Client  // default: http.DefaultClient
+	MaxRetries  int           // retries for 429 and 5xx responses (default 3)
+	RetryWait   time.Duration // wait before the first retry, doubled for each retry (default 1s)
+}
+
+// Client for the CrossRef REST API. Requests are rate limited using the
+// X-Rate-Limit headers in CrossRef's responses, and citations are cached for
+// the life of the Client. A Client is safe for concurrent use.
+type Client struct {
+	opts    Options
+	limiter *ratelimit.Limiter
+
+	mu    sync.Mutex
+	cache map[doi.DOI]*Citation
+}
+
+// DefaultClient is used by GetCitation and Search
+var DefaultClient = NewClient(Options{})
+
+// NewClient returns a new Client
+func NewClient(opts Options) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if opts.ResolverURL == "" {
+		opts.ResolverURL = DefaultResolverURL
+	}
+	opts.ResolverURL = strings.TrimSuffix(opts.ResolverURL, "/")
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.RetryWait == 0 {
+		opts.RetryWait = time.Second
+	}
+	return &Client{
+		opts:    opts,
+		limiter: ratelimit.New(50, time.Second), // CrossRef's usual limit
+		cache:   make(map[doi.DOI]*Citation),
+	}
+}
+
+// get does a GET request for the API path and decodes the response's
+// message into msg. 429 and 5xx responses are retried.
+func (c *Client) get(ctx context.Context, path string, vals url.Values, msg interface{}) error {
+	if vals == nil {
+		vals = url.Values{}
+	}
+	if c.opts.Mailto != "" {
+		vals.Set("mailto", c.opts.Mailto)
+	}
+	u := c.opts.BaseURL + path
+	if len(vals) > 0 {
+		u += "?" + vals.Encode()
+	}
+	wait := c.opts.RetryWait
+	for try := 0; ; try++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		resp, err := c.do(ctx, u)
+		if err != nil {
+			return err
+		}
+		c.limiter.Update(resp.Header)
+		retry := resp.StatusCode == 429 || resp.StatusCode >= 500
+		if retry && try < c.opts.MaxRetries {
+			resp.Body.Close()
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(secs)*time.Second > wait {
+				wait = time.Duration(secs) * time.Second
+			}
+			c.limiter.Delay(wait)
+			wait *= 2
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			io.Copy(io.Discard, resp.Body)
+			return fmt.Errorf("Server responded with status: %d", resp.StatusCode)
+		}
+		//{"status":"ok","message-type":"work","message-version":"1.0.0","message": {...}
+		body := struct {
+			Status  string
+			Message interface{}
+		}{Message: msg}
+		return json.NewDecoder(resp.Body).Decode(&body)
+	}
+}
+
+func (c *Client) do(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, `GET`, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	ua := userAgent
+	if c.opts.Mailto != "" {
+		ua = fmt.Sprintf("oats (https://github.com/psu-libraries/oats; mailto:%s)", c.opts.Mailto)
+	}
+	req.Header.Set("User-Agent", ua)
+	return c.opts.HTTPClient.Do(req)
+}
+
+// Cached returns the citation for the DOI if it has already been fetched
+func (c *Client) Cached(d doi.DOI) (*Citation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cite, ok := c.cache[d]
+	return cite, ok
+}
+
+func (c *Client) store(d doi.DOI, cite *Citation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[d] = cite
+}
+
+// Work returns the citation for the DOI
+func (c *Client) Work(ctx context.Context, d doi.DOI) (*Citation, error) {
+	if cite, ok := c.Cached(d); ok {
+		return cite, nil
+	}
+	var cite Citation
+	if err := c.get(ctx, "/works/"+d.Path(), nil, &cite); err != nil {
+		return nil, err
+	}
+	c.store(d, &cite)
+	return &cite, nil
+}
+
+// Works returns citations for the DOIs, which are fetched in batches using
+// the doi filter. DOIs that are not in CrossRef are missing from the
+// returned map.
+func (c *Client) Works(ctx context.Context, dois []doi.DOI) (map[doi.DOI]*Citation, error) {
+	found := make(map[doi.DOI]*Citation)
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		vals := url.Values{}
+		vals.Set("filter", strings.Join(batch, ","))
+		vals.Set("rows", strconv.Itoa(len(batch)))
+		batch = nil
+		var msg struct {
+			Items []Citation `json:"items"`
+		}
+		if err := c.get(ctx, "/works", vals, &msg); err != nil {
+			return err
+		}
+		for i := range msg.Items {
+			d, err := doi.Parse(msg.Items[i].DOI)
+			if err != nil {
+				continue
+			}
+			found[d] = &msg.Items[i]
+			c.store(d, &msg.Items[i])
+		}
+		return nil
+	}
+	for _, d := range dois {
+		if cite, ok := c.Cached(d); ok {
+			found[d] = cite
+			continue
+		}
+		if d == "" || strings.Contains(string(d), ",") {
+			// can't be used in a filter
+			if cite, err := c.Work(ctx, d); err == nil {
+				found[d] = cite
+			}
+			continue
+		}
+		batch = append(batch, "doi:"+d.String())
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Search returns works matching the query, ordered by CrossRef's relevance
+// score.
+func (c *Client) Search(ctx context.Context, q Query) ([]Citation, error) {
+	if q.Bibliographic == "" && q.Author == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	var msg struct {
+		Items []Citation `json:"items"`
+	}
+	if err := c.get(ctx, "/works", q.values(), &msg); err != nil {
+		return nil, err
+	}
+	return msg.Items, nil
+}
@@ -0,0 +1,146 @@
+package crossref_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/psu-libraries/oats/cassette"
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
+)
+
+func TestClientWork(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("mailto") != "oats@example.com" {
+			t.Errorf("missing mailto: %s", r.URL)
+		}
+		if ua := r.Header.Get("User-Agent"); !strings.Contains(ua, "mailto:oats@example.com") {
+			t.Errorf("unexpected User-Agent: %s", ua)
+		}
+		if r.URL.Path != "/works/10.1000/abc" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-Rate-Limit-Limit", "100")
+		w.Header().Set("X-Rate-Limit-Interval", "1s")
+		fmt.Fprint(w, `{"status":"ok","message":{"DOI":"10.1000/abc","title":["A Title"]}}`)
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{
+		BaseURL:   srv.URL,
+		Mailto:    "oats@example.com",
+		RetryWait: time.Millisecond,
+	})
+	d := doi.MustParse("10.1000/ABC")
+	cite, err := cli.Work(context.Background(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cite.Title[0] != "A Title" {
+		t.Errorf("unexpected citation: %+v", cite)
+	}
+	// cached
+	if _, err := cli.Work(context.Background(), d); err != nil || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("expected 2 calls (retry, no repeat), got %d", calls)
+	}
+}
+
+func TestClientRetryLimit(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{BaseURL: srv.URL, MaxRetries: 2, RetryWait: time.Millisecond})
+	if _, err := cli.Work(context.Background(), "10.1000/abc"); err == nil {
+		t.Error("expected an error")
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestClientCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{BaseURL: srv.URL, MaxRetries: 2})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cli.Work(ctx, "10.1000/abc")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if el := time.Since(start); el > 5*time.Second {
+		t.Errorf("canceled request waited %s for retry", el)
+	}
+}
+
+func TestClientWorks(t *testing.T) {
+	var dois []doi.DOI
+	for i := 0; i < 60; i++ {
+		dois = append(dois, doi.DOI(fmt.Sprintf("10.1000/%d", i)))
+	}
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var items []string
+		for _, f := range strings.Split(r.URL.Query().Get("filter"), ",") {
+			d := strings.TrimPrefix(f, "doi:")
+			if d == "10.1000/7" {
+				continue // not in CrossRef
+			}
+			items = append(items, fmt.Sprintf(`{"DOI":%q}`, strings.ToUpper(d)))
+		}
+		fmt.Fprintf(w, `{"status":"ok","message":{"items":[%s]}}`, strings.Join(items, ","))
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{BaseURL: srv.URL})
+	found, err := cli.Works(context.Background(), dois)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 59 || found["10.1000/7"] != nil || found["10.1000/59"] == nil {
+		t.Errorf("expected 59 citations, got %d", len(found))
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("expected 2 batch requests, got %d", calls)
+	}
+	if _, ok := cli.Cached("10.1000/12"); !ok {
+		t.Error("expected batch results to be cached")
+	}
+}
+
+func TestClientWorkCassette(t *testing.T) {
+	cas := cassette.New(t, "work")
+	cli := crossref.NewClient(crossref.Options{
+		Mailto:     "oats@example.com",
+		HTTPClient: cas.Client(),
+	})
+	cite, err := cli.Work(context.Background(), doi.MustParse("10.1093/mnras/staa2325"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cite.Volume != "498" || len(cite.Author) != 2 || cite.ContainerTitle[0] != "Monthly Notices of the Royal Astronomical Society" {
+		t.Errorf("unexpected citation: %+v", cite)
+	}
+	if _, err := cli.Work(context.Background(), doi.MustParse("10.1000/does-not-exist")); err == nil {
+		t.Error("expected error for unknown DOI")
+	}
+}
@@ -0,0 +1,98 @@
+package crossref
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Date is a CrossRef date with its actual precision: Parts is [year],
+// [year, month], or [year, month, day], or empty if the date is unknown.
+type Date struct {
+	Parts []int
+}
+
+// UnmarshalJSON decodes CrossRef's date object: {"date-parts": [[2020, 3]]}.
+// Parts after the first null (or missing) value are ignored.
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		DateParts [][]interface{} `json:"date-parts"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	d.Parts = nil
+	if len(raw.DateParts) == 0 {
+		return nil
+	}
+	for _, p := range raw.DateParts[0] {
+		var n int
+		switch v := p.(type) {
+		case float64:
+			n = int(v)
+		case string:
+			// some older records have string values
+			var err error
+			if n, err = strconv.Atoi(v); err != nil {
+				return fmt.Errorf("invalid date-parts value: %q", v)
+			}
+		}
+		if n == 0 || len(d.Parts) == 3 {
+			break
+		}
+		d.Parts = append(d.Parts, n)
+	}
+	return nil
+}
+
+// IsZero is true if the date is unknown
+func (d Date) IsZero() bool {
+	return len(d.Parts) == 0
+}
+
+// EDTF returns the date as an EDTF string with the date's precision: "2020",
+// "2020-03", or "2020-03-15". It returns "" for unknown dates.
+func (d Date) EDTF() string {
+	parts := make([]string, len(d.Parts))
+	for i, p := range d.Parts {
+		if i == 0 {
+			parts[i] = fmt.Sprintf("%04d", p)
+		} else {
+			parts[i] = fmt.Sprintf("%02d", p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Before reports whether d is known to be earlier than o. Only the parts both
+// dates have are compared: 2020 is not before 2020-03.
+func (d Date) Before(o Date) bool {
+	for i := 0; i < len(d.Parts) && i < len(o.Parts); i++ {
+		if d.Parts[i] != o.Parts[i] {
+			return d.Parts[i] < o.Parts[i]
+		}
+	}
+	return false
+}
+
+// PublicationDate returns the date a work was first published: the earliest
+// of the online, print, and issued dates. When dates agree as far as they go
+// (2020 and 2020-03), the more precise one is used. If none of these are
+// known, the date the DOI was created is used.
+func (c Citation) PublicationDate() Date {
+	var best Date
+	for _, d := range []Date{c.PublishedOnline, c.PublishedPrint, c.Issued} {
+		switch {
+		case d.IsZero():
+		case best.IsZero(), d.Before(best):
+			best = d
+		case !best.Before(d) && len(d.Parts) > len(best.Parts):
+			best = d
+		}
+	}
+	if best.IsZero() {
+		return c.Created
+	}
+	return best
+}
@@ -0,0 +1,29 @@
+package crossref_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+func TestPublicationDate(t *testing.T) {
+	table := map[string]string{
+		// online a year before print
+		`{"published-print":{"date-parts":[[2021,1]]},"published-online":{"date-parts":[[2020,11,23]]},"issued":{"date-parts":[[2020,11,23]]}}`: `2020-11-23`,
+		// partial dates and nulls
+		`{"published-print":{"date-parts":[[2019,null]]},"issued":{"date-parts":[[2019,5]]}}`: `2019-05`,
+		`{"issued":{"date-parts":[[null]]},"created":{"date-parts":[[2018,2,1]]}}`:            `2018-02-01`,
+		`{"issued":{"date-parts":[["2017","4"]]}}`:                                            `2017-04`,
+		`{"title":["no dates"]}`: ``,
+	}
+	for in, expect := range table {
+		var c crossref.Citation
+		if err := json.Unmarshal([]byte(in), &c); err != nil {
+			t.Fatal(err)
+		}
+		if out := c.PublicationDate().EDTF(); out != expect {
+			t.Errorf("for %s, expected %q, got %q", in, expect, out)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package crossref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+// cslText is a CSL-JSON text field, which may be a string, a number (e.g.,
+// volume), or a list of strings
+type cslText []string
+
+func (t *cslText) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = nil
+		if s != "" {
+			*t = cslText{s}
+		}
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err == nil {
+		*t = cslText{n.String()}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// first returns the first value or ""
+func (t cslText) first() string {
+	if len(t) == 0 {
+		return ""
+	}
+	return t[0]
+}
+
+// cslItem is CSL-JSON returned by DOI content negotiation
+type cslItem struct {
+	Type                cslText  `json:"type"`
+	Title               cslText  `json:"title"`
+	Subtitle            cslText  `json:"subtitle"`
+	Abstract            cslText  `json:"abstract"`
+	Author              []Author `json:"author"`
+	ContainerTitle      cslText  `json:"container-title"`
+	ShortContainerTitle cslText  `json:"container-title-short"`
+	Publisher           cslText  `json:"publisher"`
+	Subject             cslText  `json:"subject"`
+	Language            cslText  `json:"language"`
+	ISSN                cslText  `json:"ISSN"`
+	Issued              Date     `json:"issued"`
+	PublishedPrint      Date     `json:"published-print"`
+	PublishedOnline     Date     `json:"published-online"`
+	Volume              cslText  `json:"volume"`
+	Issue               cslText  `json:"issue"`
+	Page                cslText  `json:"page"`
+	DOI                 string   `json:"DOI"`
+	URL                 string   `json:"URL"`
+}
+
+// citation converts the CSL-JSON item to a Citation
+func (item cslItem) citation(source string) *Citation {
+	return &Citation{
+		Title:               item.Title,
+		Subtitle:            item.Subtitle,
+		Abstract:            item.Abstract.first(),
+		Author:              item.Author,
+		ContainerTitle:      item.ContainerTitle,
+		ShortContainerTitle: item.ShortContainerTitle,
+		Publisher:           item.Publisher.first(),
+		Subject:             item.Subject,
+		Language:            item.Language.first(),
+		Type:                item.Type.first(),
+		ISSN:                item.ISSN,
+		Source:              source,
+		Issued:              item.Issued,
+		PublishedPrint:      item.PublishedPrint,
+		PublishedOnline:     item.PublishedOnline,
+		Volume:              item.Volume.first(),
+		Issue:               item.Issue.first(),
+		Page:                item.Page.first(),
+		DOI:                 item.DOI,
+		URL:                 item.URL,
+	}
+}
+
+// GetCSL returns a citation for the DOI using content negotiation and the
+// DefaultClient.
+func GetCSL(d doi.DOI, source string) (*Citation, error) {
+	return DefaultClient.CSL(context.Background(), d, source)
+}
+
+// CSL returns a citation for the DOI using doi.org content negotiation, which
+// is supported by Crossref, DataCite, mEDRA, and JaLC. It has less detail
+// than Work (no funders or licenses). source is the registration agency, and
+// is saved as the citation's Source.
+func (c *Client) CSL(ctx context.Context, d doi.DOI, source string) (*Citation, error) {
+	req, err := http.NewRequestWithContext(ctx, `GET`, c.opts.ResolverURL+"/"+d.Path(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/vnd.citationstyles.csl+json")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Server responded with status: %d", resp.StatusCode)
+	}
+	var item cslItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("invalid CSL-JSON for %s: %w", d, err)
+	}
+	return item.citation(source), nil
+}
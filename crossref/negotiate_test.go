@@ -0,0 +1,103 @@
+package crossref_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+// CSL-JSON from doi.org content negotiation for a mEDRA DOI
+const medraCSL = `{
+	"type": "article-journal",
+	"title": "Cultural Heritage and Climate Change",
+	"subtitle": ["A Review"],
+	"author": [
+		{"family": "Rossi", "given": "Maria", "sequence": "first"},
+		{"literal": "Heritage Lab"},
+		{"family": "Bianchi", "given": "Luca", "ORCID": "https://orcid.org/0000-0002-1825-0097"}
+	],
+	"container-title": "Journal of Heritage Studies",
+	"container-title-short": ["J. Herit. Stud."],
+	"publisher": "Example Press",
+	"language": "en",
+	"ISSN": ["1234-5678", "8765-4321"],
+	"issued": {"date-parts": [[2020, 3]]},
+	"published-online": {"date-parts": [["2020", "2", "14"]]},
+	"volume": 12,
+	"issue": "1",
+	"page": "45-67",
+	"DOI": "10.1400/281234",
+	"URL": "https://journals.example.org/jhs/article/281234"
+}`
+
+func TestClientCSL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.citationstyles.csl+json" {
+			t.Errorf("unexpected Accept: %s", accept)
+		}
+		switch r.URL.Path {
+		case "/10.1400/281234":
+			fmt.Fprint(w, medraCSL)
+		case "/10.1400/invalid":
+			fmt.Fprint(w, `<html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{ResolverURL: srv.URL})
+	ctx := context.Background()
+
+	cite, err := cli.CSL(ctx, "10.1400/281234", "mEDRA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cite.Source != "mEDRA" || cite.Type != "article-journal" || cite.DOI != "10.1400/281234" {
+		t.Errorf("unexpected source, type, or DOI: %q, %q, %q", cite.Source, cite.Type, cite.DOI)
+	}
+	if !reflect.DeepEqual(cite.Title, []string{"Cultural Heritage and Climate Change"}) ||
+		!reflect.DeepEqual(cite.Subtitle, []string{"A Review"}) {
+		t.Errorf("unexpected title: %q: %q", cite.Title, cite.Subtitle)
+	}
+	if !reflect.DeepEqual(cite.ContainerTitle, []string{"Journal of Heritage Studies"}) ||
+		!reflect.DeepEqual(cite.ShortContainerTitle, []string{"J. Herit. Stud."}) {
+		t.Errorf("unexpected container title: %q, %q", cite.ContainerTitle, cite.ShortContainerTitle)
+	}
+	if len(cite.Author) != 3 {
+		t.Fatalf("expected 3 authors, got %d", len(cite.Author))
+	}
+	rossi, bianchi := cite.Author[0], cite.Author[2]
+	if rossi.Family != "Rossi" || rossi.Given != "Maria" || rossi.Sequence != "first" {
+		t.Errorf("unexpected author: %+v", rossi)
+	}
+	if bianchi.Family != "Bianchi" || bianchi.ORCID != "https://orcid.org/0000-0002-1825-0097" {
+		t.Errorf("unexpected author: %+v", bianchi)
+	}
+	if got := cite.Issued.EDTF(); got != "2020-03" {
+		t.Errorf("unexpected issued date: %s", got)
+	}
+	if got := cite.PublicationDate().EDTF(); got != "2020-02-14" {
+		t.Errorf("unexpected publication date: %s", got)
+	}
+	if cite.Publisher != "Example Press" || cite.Language != "en" || len(cite.ISSN) != 2 {
+		t.Errorf("unexpected publisher, language, or ISSN: %q, %q, %q", cite.Publisher, cite.Language, cite.ISSN)
+	}
+	if cite.URL != "https://journals.example.org/jhs/article/281234" {
+		t.Errorf("unexpected landing page: %q", cite.URL)
+	}
+	if cite.Volume != "12" || cite.Issue != "1" || cite.Page != "45-67" {
+		t.Errorf("unexpected volume, issue, or page: %q, %q, %q", cite.Volume, cite.Issue, cite.Page)
+	}
+
+	if _, err := cli.CSL(ctx, "10.1400/missing", "mEDRA"); err == nil {
+		t.Error("expected error for 404")
+	}
+	if _, err := cli.CSL(ctx, "10.1400/invalid", "mEDRA"); err == nil {
+		t.Error("expected error for invalid CSL-JSON")
+	}
+}
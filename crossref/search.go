@@ -0,0 +1,49 @@
+package crossref
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query is a bibliographic search of CrossRef's works endpoint
+type Query struct {
+	Bibliographic string // title, journal, and other citation text
+	Author        string // author names
+	FromYear      int    // earliest publication year (optional)
+	UntilYear     int    // latest publication year (optional)
+	Rows          int    // maximum number of results (default 5)
+}
+
+// values returns the query as URL parameters
+func (q Query) values() url.Values {
+	vals := url.Values{}
+	if q.Bibliographic != "" {
+		vals.Set("query.bibliographic", q.Bibliographic)
+	}
+	if q.Author != "" {
+		vals.Set("query.author", q.Author)
+	}
+	var filters []string
+	if q.FromYear > 0 {
+		filters = append(filters, "from-pub-date:"+strconv.Itoa(q.FromYear))
+	}
+	if q.UntilYear > 0 {
+		filters = append(filters, "until-pub-date:"+strconv.Itoa(q.UntilYear))
+	}
+	if len(filters) > 0 {
+		vals.Set("filter", strings.Join(filters, ","))
+	}
+	rows := q.Rows
+	if rows <= 0 {
+		rows = 5
+	}
+	vals.Set("rows", strconv.Itoa(rows))
+	return vals
+}
+
+// Search returns works matching the query using the DefaultClient
+func Search(q Query) ([]Citation, error) {
+	return DefaultClient.Search(context.Background(), q)
+}
@@ -0,0 +1,79 @@
+package crossref_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+func TestClientSearch(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"status":"ok","message":{"items":[
+			{"DOI":"10.1000/a","title":["Soil Carbon"],"score":42.5},
+			{"DOI":"10.1000/b","title":["Soil Nitrogen"],"score":12.1}
+		]}}`)
+	}))
+	defer srv.Close()
+	cli := crossref.NewClient(crossref.Options{BaseURL: srv.URL})
+	ctx := context.Background()
+
+	table := []struct {
+		q      crossref.Query
+		expect map[string]string // expected parameters ("" for absent)
+	}{
+		{
+			q: crossref.Query{Bibliographic: "Soil Carbon Geoderma", Author: "Smith", FromYear: 2019, UntilYear: 2021},
+			expect: map[string]string{
+				"query.bibliographic": "Soil Carbon Geoderma",
+				"query.author":        "Smith",
+				"filter":              "from-pub-date:2019,until-pub-date:2021",
+				"rows":                "5",
+			},
+		},
+		{
+			q: crossref.Query{Bibliographic: "Soil Carbon", FromYear: 2019, Rows: 20},
+			expect: map[string]string{
+				"query.bibliographic": "Soil Carbon",
+				"query.author":        "",
+				"filter":              "from-pub-date:2019",
+				"rows":                "20",
+			},
+		},
+		{
+			q: crossref.Query{Author: "Smith"},
+			expect: map[string]string{
+				"query.bibliographic": "",
+				"query.author":        "Smith",
+				"filter":              "",
+				"rows":                "5",
+			},
+		},
+	}
+	for _, row := range table {
+		cites, err := cli.Search(ctx, row.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cites) != 2 || cites[0].DOI != "10.1000/a" || cites[0].Score != 42.5 {
+			t.Errorf("unexpected results: %+v", cites)
+		}
+		for k, v := range row.expect {
+			if got := query.Get(k); got != v {
+				t.Errorf("%+v: expected %s=%q, got %q", row.q, k, v, got)
+			}
+		}
+	}
+	if _, err := cli.Search(ctx, crossref.Query{FromYear: 2020}); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
@@ -0,0 +1,62 @@
+package crossref
+
+// Update is an update-to or updated-by relation: a retraction, correction, or
+// other notice that changes the status of a work.
+type Update struct {
+	DOI     string `json:"DOI"`  // the other work (notice or updated work)
+	Type    string `json:"type"` // e.g. "retraction" or "correction"
+	Label   string `json:"label"`
+	Source  string `json:"source"` // e.g. "publisher" or "retraction-watch"
+	Updated Date   `json:"updated"`
+}
+
+// Update statuses returned by UpdateStatus, in order of severity
+const (
+	StatusRetracted = "Retracted"
+	StatusConcern   = "Expression of Concern"
+	StatusCorrected = "Corrected"
+)
+
+// updateStatuses maps CrossRef update types to statuses
+var updateStatuses = map[string]string{
+	"retraction":            StatusRetracted,
+	"withdrawal":            StatusRetracted,
+	"removal":               StatusRetracted,
+	"partial_retraction":    StatusRetracted,
+	"expression_of_concern": StatusConcern,
+	"correction":            StatusCorrected,
+	"erratum":               StatusCorrected,
+	"corrigendum":           StatusCorrected,
+	"addendum":              StatusCorrected,
+	"clarification":         StatusCorrected,
+}
+
+// statusRank orders statuses by severity
+var statusRank = map[string]int{
+	StatusRetracted: 3,
+	StatusConcern:   2,
+	StatusCorrected: 1,
+}
+
+// UpdateStatus returns the most severe status from the work's updated-by
+// relations (StatusRetracted, StatusConcern, or StatusCorrected) and the
+// update with that status. It returns "" if the work has not been updated.
+func (c Citation) UpdateStatus() (string, *Update) {
+	var (
+		status string
+		update *Update
+	)
+	for i, u := range c.UpdatedBy {
+		s := updateStatuses[u.Type]
+		if statusRank[s] > statusRank[status] {
+			status, update = s, &c.UpdatedBy[i]
+		}
+	}
+	return status, update
+}
+
+// Retracted is true if the work has been retracted or withdrawn
+func (c Citation) Retracted() bool {
+	s, _ := c.UpdateStatus()
+	return s == StatusRetracted
+}
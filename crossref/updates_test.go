@@ -0,0 +1,37 @@
+package crossref_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/psu-libraries/oats/crossref"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	table := map[string]string{
+		`{"title":["not updated"]}`: ``,
+		`{"updated-by":[{"DOI":"10.1000/c1","type":"correction","label":"Correction","source":"publisher","updated":{"date-parts":[[2021,3,1]]}}]}`: crossref.StatusCorrected,
+		// most severe wins
+		`{"updated-by":[{"DOI":"10.1000/c1","type":"erratum"},{"DOI":"10.1000/r1","type":"retraction"},{"DOI":"10.1000/e1","type":"expression_of_concern"}]}`: crossref.StatusRetracted,
+		`{"updated-by":[{"DOI":"10.1000/e1","type":"expression_of_concern"},{"DOI":"10.1000/c1","type":"corrigendum"}]}`:                                      crossref.StatusConcern,
+		`{"updated-by":[{"DOI":"10.1000/x1","type":"new_version"}]}`:                                                                                          ``,
+		// a retraction notice is not itself retracted
+		`{"update-to":[{"DOI":"10.1000/original","type":"retraction"}]}`: ``,
+	}
+	for in, expect := range table {
+		var c crossref.Citation
+		if err := json.Unmarshal([]byte(in), &c); err != nil {
+			t.Fatal(err)
+		}
+		status, update := c.UpdateStatus()
+		if status != expect {
+			t.Errorf("for %s, expected %q, got %q", in, expect, status)
+		}
+		if (status == "") != (update == nil) {
+			t.Errorf("for %s, expected an update with status %q", in, status)
+		}
+		if c.Retracted() != (expect == crossref.StatusRetracted) {
+			t.Errorf("for %s, unexpected Retracted()", in)
+		}
+	}
+}
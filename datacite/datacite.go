@@ -0,0 +1,248 @@
+// Package datacite is a client for the DataCite REST API. Works are returned
+// as crossref.Citations so they can be used wherever CrossRef metadata is.
+package datacite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/doi"
+)
+
+// Source is the value of Source for citations from DataCite
+const Source = "DataCite"
+
+// DefaultBaseURL is the DataCite REST API
+const DefaultBaseURL = `https://api.datacite.org`
+
+// Options for NewClient
+type Options struct {
+	BaseURL    string       // default: DefaultBaseURL
+	HTTPClient *http.Client // default: http.DefaultClient
+}
+
+// Client for the DataCite REST API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// DefaultClient is used by GetCitation
+var DefaultClient = NewClient(Options{})
+
+// NewClient returns a new Client
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	return c
+}
+
+// work is the attributes of a DataCite DOI record
+type work struct {
+	DOI    string `json:"doi"`
+	URL    string `json:"url"` // landing page
+	Titles []struct {
+		Title     string `json:"title"`
+		TitleType string `json:"titleType"` // empty for the main title
+	} `json:"titles"`
+	Creators []struct {
+		Name            string `json:"name"`
+		NameType        string `json:"nameType"` // Personal or Organizational
+		GivenName       string `json:"givenName"`
+		FamilyName      string `json:"familyName"`
+		NameIdentifiers []struct {
+			NameIdentifier       string `json:"nameIdentifier"`
+			NameIdentifierScheme string `json:"nameIdentifierScheme"`
+		} `json:"nameIdentifiers"`
+	} `json:"creators"`
+	Publisher       string      `json:"publisher"`
+	PublicationYear interface{} `json:"publicationYear"` // number or string
+	Subjects        []struct {
+		Subject string `json:"subject"`
+	} `json:"subjects"`
+	Dates []struct {
+		Date     string `json:"date"`
+		DateType string `json:"dateType"`
+	} `json:"dates"`
+	Language string `json:"language"`
+	Types    struct {
+		ResourceTypeGeneral string `json:"resourceTypeGeneral"`
+		Citeproc            string `json:"citeproc"`
+	} `json:"types"`
+	Descriptions []struct {
+		Description     string `json:"description"`
+		DescriptionType string `json:"descriptionType"`
+	} `json:"descriptions"`
+	RightsList []struct {
+		RightsURI string `json:"rightsUri"`
+	} `json:"rightsList"`
+	FundingReferences []struct {
+		FunderName           string `json:"funderName"`
+		FunderIdentifier     string `json:"funderIdentifier"`
+		FunderIdentifierType string `json:"funderIdentifierType"`
+		AwardNumber          string `json:"awardNumber"`
+	} `json:"fundingReferences"`
+	Container struct {
+		Title          string `json:"title"`
+		Identifier     string `json:"identifier"`
+		IdentifierType string `json:"identifierType"`
+		Volume         string `json:"volume"`
+		Issue          string `json:"issue"`
+		FirstPage      string `json:"firstPage"`
+		LastPage       string `json:"lastPage"`
+	} `json:"container"`
+	Created string `json:"created"` // record creation timestamp
+}
+
+// GetCitation returns the DataCite metadata for the DOI as a citation using
+// the DefaultClient
+func GetCitation(d doi.DOI) (*crossref.Citation, error) {
+	return DefaultClient.GetCitation(context.Background(), d)
+}
+
+// GetCitation returns the DataCite metadata for the DOI as a citation
+func (c *Client) GetCitation(ctx context.Context, d doi.DOI) (*crossref.Citation, error) {
+	req, err := http.NewRequestWithContext(ctx, `GET`, c.baseURL+"/dois/"+d.Path(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/vnd.api+json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Server responded with status: %d", resp.StatusCode)
+	}
+	// {"data": {"id": "10.5061/dryad.8515", "type": "dois", "attributes": {...}}}
+	var body struct {
+		Data struct {
+			Attributes work `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data.Attributes.citation(), nil
+}
+
+// citation converts the DataCite work to a citation
+func (w work) citation() *crossref.Citation {
+	c := &crossref.Citation{
+		Publisher: w.Publisher,
+		Language:  w.Language,
+		Type:      w.Types.Citeproc, // CSL type, e.g. "dataset" or "paper-conference"
+		Source:    Source,
+		Volume:    w.Container.Volume,
+		Issue:     w.Container.Issue,
+		DOI:       w.DOI,
+		URL:       w.URL,
+		Created:   parseDate(w.Created),
+	}
+	for _, t := range w.Titles {
+		switch t.TitleType {
+		case "":
+			c.Title = append(c.Title, t.Title)
+		case "Subtitle":
+			c.Subtitle = append(c.Subtitle, t.Title)
+		}
+	}
+	for i, cr := range w.Creators {
+		a := crossref.Author{Sequence: "additional"}
+		if i == 0 {
+			a.Sequence = "first"
+		}
+		if cr.NameType != "Organizational" && cr.FamilyName != "" {
+			a.Family, a.Given = cr.FamilyName, cr.GivenName
+		} else {
+			a.Name = cr.Name
+		}
+		for _, id := range cr.NameIdentifiers {
+			if id.NameIdentifierScheme == "ORCID" {
+				a.ORCID = id.NameIdentifier
+			}
+		}
+		c.Author = append(c.Author, a)
+	}
+	for _, s := range w.Subjects {
+		c.Subject = append(c.Subject, s.Subject)
+	}
+	for _, d := range w.Dates {
+		if d.DateType == "Issued" {
+			c.Issued = parseDate(d.Date)
+		}
+	}
+	if c.Issued.IsZero() {
+		c.Issued = parseDate(fmt.Sprint(w.PublicationYear))
+	}
+	for _, d := range w.Descriptions {
+		if d.DescriptionType == "Abstract" {
+			c.Abstract = d.Description
+			break
+		}
+	}
+	for _, r := range w.RightsList {
+		if r.RightsURI != "" {
+			c.License = append(c.License, crossref.License{URL: r.RightsURI, ContentVersion: "unspecified"})
+		}
+	}
+	for _, f := range w.FundingReferences {
+		funder := crossref.Funder{Name: f.FunderName}
+		if f.FunderIdentifierType == "Crossref Funder ID" {
+			if fd, err := doi.Parse(f.FunderIdentifier); err == nil {
+				funder.DOI = fd.String()
+			}
+		}
+		if f.AwardNumber != "" {
+			funder.Award = []string{f.AwardNumber}
+		}
+		c.Funder = append(c.Funder, funder)
+	}
+	if w.Container.Title != "" {
+		c.ContainerTitle = []string{w.Container.Title}
+	}
+	if w.Container.IdentifierType == "ISSN" {
+		c.ISSN = []string{w.Container.Identifier}
+	}
+	if w.Container.FirstPage != "" {
+		c.Page = w.Container.FirstPage
+		if w.Container.LastPage != "" {
+			c.Page += "-" + w.Container.LastPage
+		}
+	}
+	return c
+}
+
+// parseDate parses DataCite dates, which are W3CDTF ("2019", "2019-05",
+// "2019-05-03", or a timestamp) or ranges ("2019-01/2019-06"). Only the start
+// of a range is used. Invalid dates are zero.
+func parseDate(s string) crossref.Date {
+	var d crossref.Date
+	s = strings.SplitN(s, "/", 2)[0]
+	s = strings.SplitN(s, "T", 2)[0]
+	if s == "" {
+		return d
+	}
+	for _, p := range strings.Split(s, "-") {
+		n, err := strconv.Atoi(p)
+		if err != nil || n == 0 || len(d.Parts) == 3 {
+			return crossref.Date{}
+		}
+		d.Parts = append(d.Parts, n)
+	}
+	return d
+}
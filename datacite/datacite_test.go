@@ -0,0 +1,79 @@
+package datacite
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testWork = `{
+	"doi": "10.26207/abcd-1234",
+	"url": "https://scholarsphere.psu.edu/resources/abcd",
+	"titles": [
+		{"title": "Soil Moisture Measurements"},
+		{"title": "Central Pennsylvania, 2015-2019", "titleType": "Subtitle"},
+		{"title": "Mesures d'humidité du sol", "titleType": "TranslatedTitle"}
+	],
+	"creators": [
+		{"name": "Smith, Jane", "nameType": "Personal", "givenName": "Jane", "familyName": "Smith",
+		 "nameIdentifiers": [{"nameIdentifier": "https://orcid.org/0000-0002-1825-0097", "nameIdentifierScheme": "ORCID"}]},
+		{"name": "Penn State Soil Lab", "nameType": "Organizational"}
+	],
+	"publisher": "ScholarSphere",
+	"publicationYear": 2020,
+	"dates": [{"date": "2019-12-01/2020-01-15", "dateType": "Collected"}],
+	"types": {"resourceTypeGeneral": "Dataset", "citeproc": "dataset"},
+	"descriptions": [{"description": "Hourly readings.", "descriptionType": "Abstract"}],
+	"rightsList": [{"rights": "CC BY", "rightsUri": "https://creativecommons.org/licenses/by/4.0/"}],
+	"fundingReferences": [{"funderName": "National Science Foundation", "funderIdentifier": "https://doi.org/10.13039/100000001",
+		"funderIdentifierType": "Crossref Funder ID", "awardNumber": "EAR-1234"}],
+	"created": "2020-02-03T15:04:05Z"
+}`
+
+func TestCitation(t *testing.T) {
+	var w work
+	if err := json.Unmarshal([]byte(testWork), &w); err != nil {
+		t.Fatal(err)
+	}
+	c := w.citation()
+	if len(c.Title) != 1 || c.Title[0] != "Soil Moisture Measurements" {
+		t.Errorf("unexpected title: %v", c.Title)
+	}
+	if len(c.Subtitle) != 1 {
+		t.Errorf("unexpected subtitle: %v", c.Subtitle)
+	}
+	if len(c.Author) != 2 || c.Author[0].Family != "Smith" || c.Author[0].ORCID == "" || c.Author[1].Name != "Penn State Soil Lab" {
+		t.Errorf("unexpected authors: %+v", c.Author)
+	}
+	if d := c.PublicationDate().EDTF(); d != "2020" {
+		t.Errorf("expected publication date 2020, got %q", d)
+	}
+	if c.Created.EDTF() != "2020-02-03" {
+		t.Errorf("unexpected created date: %v", c.Created)
+	}
+	if len(c.Funder) != 1 || c.Funder[0].DOI != "10.13039/100000001" || c.Funder[0].Award[0] != "EAR-1234" {
+		t.Errorf("unexpected funders: %+v", c.Funder)
+	}
+	if c.URL != "https://scholarsphere.psu.edu/resources/abcd" {
+		t.Errorf("unexpected landing page: %q", c.URL)
+	}
+	if c.Abstract != "Hourly readings." || c.Source != Source || len(c.License) != 1 {
+		t.Errorf("unexpected citation: %+v", c)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	table := map[string]string{
+		"2019":                 "2019",
+		"2019-05":              "2019-05",
+		"2019-05-03T10:00:00Z": "2019-05-03",
+		"2019-01/2019-06":      "2019-01",
+		"":                     "",
+		"<nil>":                "",
+		"May 2019":             "",
+	}
+	for in, expect := range table {
+		if out := parseDate(in).EDTF(); out != expect {
+			t.Errorf("for %q, expected %q, got %q", in, expect, out)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package doi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultBaseURL is the doi.org proxy, which provides the handle and RA APIs
+const DefaultBaseURL = `https://doi.org`
+
+// Options for NewClient
+type Options struct {
+	BaseURL    string       // default: DefaultBaseURL
+	HTTPClient *http.Client // default: http.DefaultClient
+}
+
+// Client queries doi.org's handle and registration agency APIs. Results are
+// cached for the life of the Client. A Client is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	resolutions map[DOI]Resolution
+	agencies    map[DOI]string
+}
+
+// DefaultClient is used by the package-level RA and Resolve functions
+var DefaultClient = NewClient(Options{})
+
+// NewClient returns a new Client
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient:  opts.HTTPClient,
+		resolutions: make(map[DOI]Resolution),
+		agencies:    make(map[DOI]string),
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	return c
+}
+
+// get does a GET request for the API path
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, `GET`, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	return c.httpClient.Do(req)
+}
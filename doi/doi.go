@@ -0,0 +1,118 @@
+// Package doi parses and normalises Digital Object Identifiers and formats
+// them for the services used by oats.
+package doi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalid is returned by Parse for strings that don't include a DOI
+var ErrInvalid = errors.New("not a DOI")
+
+// DOI is a normalised DOI name: lower case, without a URL or "doi:" prefix,
+// and unescaped, e.g., "10.1093/mnras/staa3102". DOIs are case-insensitive,
+// so normalised DOIs can be compared with ==.
+type DOI string
+
+// prefix "10." + registrant code, then "/" + suffix (any printable characters)
+var doiRE = regexp.MustCompile(`(?i)10\.\d{4,9}(?:\.\d+)*/[^\s"]+`)
+
+// characters that may follow a DOI in running text
+const trailingPunct = `.,;:'>`
+
+// Parse extracts a DOI from s, which may be a bare DOI, a doi.org URL, a
+// "doi:" URN, or text like "DOI: 10.1080/09518398.2019.1678783".
+// Percent-encoding is decoded and trailing punctuation is removed. If s does
+// not contain a DOI, Parse returns ErrInvalid.
+func Parse(s string) (DOI, error) {
+	in := s
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "%") {
+		if unesc, err := url.PathUnescape(s); err == nil {
+			s = unesc
+		}
+	}
+	match := doiRE.FindString(s)
+	if match == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalid, in)
+	}
+	match = trimTrailing(match)
+	if strings.HasSuffix(match, "/") {
+		return "", fmt.Errorf("%w: %q has an empty suffix", ErrInvalid, in)
+	}
+	return DOI(strings.ToLower(match)), nil
+}
+
+// MustParse is like Parse but panics if s doesn't include a DOI
+func MustParse(s string) DOI {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// trimTrailing removes trailing punctuation and unbalanced closing brackets
+func trimTrailing(s string) string {
+	for len(s) > 0 {
+		last := s[len(s)-1]
+		switch {
+		case strings.IndexByte(trailingPunct, last) >= 0:
+			s = s[:len(s)-1]
+		case last == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+			s = s[:len(s)-1]
+		case last == ']' && strings.Count(s, "[") < strings.Count(s, "]"):
+			s = s[:len(s)-1]
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+// String returns the normalised DOI
+func (d DOI) String() string {
+	return string(d)
+}
+
+// Prefix returns the DOI's registrant prefix, e.g., "10.1093"
+func (d DOI) Prefix() string {
+	if i := strings.IndexByte(string(d), '/'); i > 0 {
+		return string(d[:i])
+	}
+	return ""
+}
+
+// Suffix returns the part of the DOI after the prefix
+func (d DOI) Suffix() string {
+	if i := strings.IndexByte(string(d), '/'); i > 0 {
+		return string(d[i+1:])
+	}
+	return ""
+}
+
+// Path returns the DOI escaped for use as a URL path, as expected by the
+// CrossRef (/works/{doi}) and Unpaywall (/v2/{doi}) APIs. Each "/"-separated
+// part is escaped; the "/" characters are not.
+func (d DOI) Path() string {
+	parts := strings.Split(string(d), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// URL returns the DOI's https://doi.org URL
+func (d DOI) URL() string {
+	return "https://doi.org/" + d.Path()
+}
+
+// URN returns the DOI with a "doi:" prefix. This is the form used as keys in
+// ScholarSphere's DOI list.
+func (d DOI) URN() string {
+	return "doi:" + string(d)
+}
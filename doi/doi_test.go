@@ -0,0 +1,60 @@
+package doi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+func TestParse(t *testing.T) {
+	table := map[string]string{
+		``:                       ``,
+		`-`:                      ``,
+		`asdf`:                   ``,
+		`10.1234/`:               ``,
+		`10.1093/mnras/staa3102`: `10.1093/mnras/staa3102`,
+		`https://doi.org/10.1016/j.jde.2019.11.028`:                    `10.1016/j.jde.2019.11.028`,
+		`http://dx.doi.org/10.1016/J.JDE.2019.11.028`:                  `10.1016/j.jde.2019.11.028`,
+		`doi:10.1016/j.jde.2019.11.028`:                                `10.1016/j.jde.2019.11.028`,
+		`DOI: 10.1080/09518398.2019.1678783`:                           `10.1080/09518398.2019.1678783`,
+		`https://doi.org/10.1177%2F0276146720949636`:                   `10.1177/0276146720949636`,
+		` 10.1128/msphere.00864-20 `:                                   `10.1128/msphere.00864-20`,
+		`10.33423/ajm.v21i4.4555`:                                      `10.33423/ajm.v21i4.4555`,
+		`10.1007/978-3-030-40274-7_89`:                                 `10.1007/978-3-030-40274-7_89`,
+		`(see 10.1093/mnras/staa3102).`:                                `10.1093/mnras/staa3102`,
+		`10.1016/s0140-6736(20)30183-5.`:                               `10.1016/s0140-6736(20)30183-5`,
+		`10.1002/(sici)1097-4636(199706)35:4<415::aid-jbm1>3.0.co;2-l`: `10.1002/(sici)1097-4636(199706)35:4<415::aid-jbm1>3.0.co;2-l`,
+	}
+	for in, expect := range table {
+		out, err := doi.Parse(in)
+		if string(out) != expect {
+			t.Errorf(`for %s, expected %s, got: %s`, in, expect, out)
+		}
+		if expect == "" && !errors.Is(err, doi.ErrInvalid) {
+			t.Errorf(`for %s, expected ErrInvalid, got: %v`, in, err)
+		}
+	}
+}
+
+func TestForms(t *testing.T) {
+	d := doi.MustParse(`https://doi.org/10.1002/(SICI)1097-4636(199706)35:4<415::AID-JBM1>3.0.CO;2-L`)
+	table := [][2]string{
+		{d.Prefix(), `10.1002`},
+		{d.Path(), `10.1002/%28sici%291097-4636%28199706%2935:4%3C415::aid-jbm1%3E3.0.co%3B2-l`},
+		{d.URL(), `https://doi.org/10.1002/%28sici%291097-4636%28199706%2935:4%3C415::aid-jbm1%3E3.0.co%3B2-l`},
+		{d.URN(), `doi:10.1002/(sici)1097-4636(199706)35:4<415::aid-jbm1>3.0.co;2-l`},
+	}
+	// "/" in the suffix is not escaped
+	slashed := doi.MustParse(`10.1093/mnras/staa3102`)
+	table = append(table,
+		[2]string{slashed.Path(), `10.1093/mnras/staa3102`},
+		[2]string{slashed.URL(), `https://doi.org/10.1093/mnras/staa3102`},
+		[2]string{doi.MustParse(`10.1016/S0140-6736(20)30183-5/fulltext`).Path(), `10.1016/s0140-6736%2820%2930183-5/fulltext`},
+	)
+	for _, row := range table {
+		if row[0] != row[1] {
+			t.Errorf(`expected %s, got: %s`, row[1], row[0])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package doi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Registration agencies returned by RA
+const (
+	RACrossref = "Crossref"
+	RADataCite = "DataCite"
+	RAmEDRA    = "mEDRA"
+	RAJaLC     = "JaLC"
+)
+
+// RA returns the name of the registration agency for the DOI using the
+// DefaultClient.
+func RA(d DOI) (string, error) {
+	return DefaultClient.RA(context.Background(), d)
+}
+
+// RA returns the name of the registration agency for the DOI (e.g.,
+// "Crossref" or "DataCite") using doi.org's RA API.
+func (c *Client) RA(ctx context.Context, d DOI) (string, error) {
+	c.mu.Lock()
+	ra, ok := c.agencies[d]
+	c.mu.Unlock()
+	if ok {
+		return ra, nil
+	}
+	resp, err := c.get(ctx, "/ra/"+d.Path())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Server responded with status: %d", resp.StatusCode)
+	}
+	ra, err = decodeRA(d, resp.Body)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.agencies[d] = ra
+	c.mu.Unlock()
+	return ra, nil
+}
+
+// decodeRA decodes the RA API response:
+//
+//	[{"DOI": "10.5061/dryad.8515", "RA": "DataCite"}]
+//
+// For unknown DOIs, the response has a status instead of an RA:
+//
+//	[{"DOI": "10.9999/xyz", "status": "DOI does not exist"}]
+func decodeRA(d DOI, r io.Reader) (string, error) {
+	var body []struct {
+		DOI    string
+		RA     string
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return "", err
+	}
+	if len(body) == 0 {
+		return "", fmt.Errorf("no registration agency for %s", d)
+	}
+	if body[0].RA == "" {
+		return "", fmt.Errorf("no registration agency for %s: %s", d, body[0].Status)
+	}
+	return body[0].RA, nil
+}
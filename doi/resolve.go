@@ -0,0 +1,123 @@
+package doi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// handle API response codes
+const (
+	handleOK       = 1
+	handleNotFound = 100
+	handleNoValues = 200
+)
+
+// Resolution is the result of resolving a DOI with the handle API
+type Resolution struct {
+	DOI        DOI
+	Exists     bool   // the DOI is registered
+	URL        string // target URL, if any
+	Agency     string // registration agency, if known
+	StatusCode int    // handle API's HTTP status (0 if the request failed)
+	Err        error  // request or API error, if any
+}
+
+// Resolves is true if the DOI is registered and has a target URL
+func (r Resolution) Resolves() bool {
+	return r.Exists && r.URL != ""
+}
+
+// Reason explains why the DOI doesn't resolve. It returns "" if it does.
+func (r Resolution) Reason() string {
+	switch {
+	case r.Resolves():
+		return ""
+	case r.DOI == "":
+		return "no DOI"
+	case r.Err != nil:
+		return r.Err.Error()
+	case !r.Exists:
+		return "DOI is not registered"
+	default:
+		return "DOI has no target URL"
+	}
+}
+
+// Resolve resolves the DOI using the DefaultClient
+func Resolve(d DOI) Resolution {
+	return DefaultClient.Resolve(context.Background(), d)
+}
+
+// Resolve looks up the DOI's target URL using doi.org's handle API and, if
+// the DOI exists, its registration agency. Results are cached unless there
+// was an error.
+func (c *Client) Resolve(ctx context.Context, d DOI) Resolution {
+	if d == "" {
+		return Resolution{}
+	}
+	c.mu.Lock()
+	res, ok := c.resolutions[d]
+	c.mu.Unlock()
+	if ok {
+		return res
+	}
+	res = c.resolve(ctx, d)
+	if res.Exists {
+		// agency is informational: ignore errors
+		res.Agency, _ = c.RA(ctx, d)
+	}
+	if res.Err == nil {
+		c.mu.Lock()
+		c.resolutions[d] = res
+		c.mu.Unlock()
+	}
+	return res
+}
+
+func (c *Client) resolve(ctx context.Context, d DOI) Resolution {
+	res := Resolution{DOI: d}
+	resp, err := c.get(ctx, "/api/handles/"+d.Path())
+	if err != nil {
+		res.Err = fmt.Errorf("handle API request failed: %w", err)
+		return res
+	}
+	defer resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+	// {"responseCode": 1, "handle": "10.1000/1", "values": [{"index": 1,
+	// "type": "URL", "data": {"format": "string", "value": "https://..."}}]}
+	var body struct {
+		ResponseCode int `json:"responseCode"`
+		Values       []struct {
+			Type string `json:"type"`
+			Data struct {
+				Value interface{} `json:"value"`
+			} `json:"data"`
+		} `json:"values"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+	switch {
+	case resp.StatusCode == 404 || body.ResponseCode == handleNotFound:
+		return res
+	case resp.StatusCode != 200:
+		res.Err = fmt.Errorf("handle API responded with status: %d", resp.StatusCode)
+		return res
+	case decodeErr != nil:
+		res.Err = fmt.Errorf("invalid handle API response: %w", decodeErr)
+		return res
+	case body.ResponseCode == handleNoValues:
+		res.Exists = true
+		return res
+	case body.ResponseCode != handleOK:
+		res.Err = fmt.Errorf("handle API error: response code %d", body.ResponseCode)
+		return res
+	}
+	res.Exists = true
+	for _, v := range body.Values {
+		if u, ok := v.Data.Value.(string); ok && v.Type == "URL" {
+			res.URL = u
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package doi_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+// handleServer is a stand-in for doi.org's handle and RA APIs
+func handleServer(t *testing.T, targets map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/handles/"):
+			d := strings.TrimPrefix(r.URL.Path, "/api/handles/")
+			target, ok := targets[d]
+			switch {
+			case !ok:
+				w.WriteHeader(404)
+				fmt.Fprintf(w, `{"responseCode":100,"handle":%q}`, d)
+			case target == "":
+				fmt.Fprintf(w, `{"responseCode":200,"handle":%q}`, d)
+			default:
+				fmt.Fprintf(w, `{"responseCode":1,"handle":%q,"values":[{"index":100,"type":"HS_ADMIN","data":{"format":"admin","value":{}}},{"index":1,"type":"URL","data":{"format":"string","value":%q}}]}`, d, target)
+			}
+		case strings.HasPrefix(r.URL.Path, "/ra/"):
+			d := strings.TrimPrefix(r.URL.Path, "/ra/")
+			fmt.Fprintf(w, `[{"DOI":%q,"RA":"Crossref"}]`, d)
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+			w.WriteHeader(500)
+		}
+	}))
+}
+
+func TestResolve(t *testing.T) {
+	srv := handleServer(t, map[string]string{
+		`10.1093/mnras/staa3102`:        `https://academic.oup.com/mnras/article/500/2/2312/5943523`,
+		`10.1016/j.jde.2019.11.028`:     `https://linkinghub.elsevier.com/retrieve/pii/S0022039619305595`,
+		`10.1080/09518398.2019.1678783`: `https://www.tandfonline.com/doi/full/10.1080/09518398.2019.1678783`,
+		`10.1007/978-3-030-40274-7_89`:  `https://link.springer.com/10.1007/978-3-030-40274-7_89`,
+		`10.1234/no-url`:                ``,
+	})
+	defer srv.Close()
+	cli := doi.NewClient(doi.Options{BaseURL: srv.URL})
+	table := map[string]bool{
+		`10.1093/mnras/staa3102`:                    true,
+		`https://doi.org/10.1016/j.jde.2019.11.028`: true,
+		`DOI: 10.1080/09518398.2019.1678783`:        true,
+		`asdf`:                                      false,
+		`10.1128/jokejokesjokes`:                    false,
+		``:                                          false,
+		`10.1007/978-3-030-40274-7_89`:              true,
+		`10.1234/no-url`:                            false,
+	}
+	for in, expect := range table {
+		d, _ := doi.Parse(in)
+		res := cli.Resolve(context.Background(), d)
+		if out := res.Resolves(); out != expect {
+			t.Errorf(`for %s, expected %v, got %v (%s)`, in, expect, out, res.Reason())
+		}
+		if expect && res.Agency != doi.RACrossref {
+			t.Errorf(`for %s, expected agency %s, got %q`, in, doi.RACrossref, res.Agency)
+		}
+	}
+	if res := cli.Resolve(context.Background(), "10.1128/jokejokesjokes"); res.Exists || res.StatusCode != 404 || res.Reason() != "DOI is not registered" {
+		t.Errorf("unexpected resolution for unregistered DOI: %+v", res)
+	}
+	if res := cli.Resolve(context.Background(), "10.1234/no-url"); !res.Exists || res.Reason() != "DOI has no target URL" {
+		t.Errorf("unexpected resolution for DOI without URL: %+v", res)
+	}
+}
+
+func TestResolveError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+	res := doi.NewClient(doi.Options{BaseURL: srv.URL}).Resolve(context.Background(), "10.1093/mnras/staa3102")
+	if res.Exists || res.StatusCode != 503 || res.Err == nil {
+		t.Errorf("expected error for unavailable server, got %+v", res)
+	}
+}
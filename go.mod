@@ -9,6 +9,7 @@ require (
 	github.com/mehanizm/airtable v0.2.5
 	github.com/muesli/coral v1.0.0
 	github.com/zRedShift/mimemagic v1.2.0
+	golang.org/x/text v0.3.7
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -17,6 +18,5 @@ require (
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/net v0.0.0-20210908191846-a5e095526f91 // indirect
-	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
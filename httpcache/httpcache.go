@@ -0,0 +1,129 @@
+// Package httpcache is an on-disk cache for HTTP responses. A Transport can be
+// used with any http.Client; successful GET responses are saved in the
+// cache directory and reused until they expire.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fromCacheHeader is set on responses read from the cache
+const fromCacheHeader = "X-From-Cache"
+
+// headers that affect the response and are part of the cache key
+var keyHeaders = []string{"Accept", "Authorization", "X-API-Key"}
+
+// Transport is an http.RoundTripper that caches GET responses with status
+// 200 for TTL. Other requests and responses pass through unchanged.
+type Transport struct {
+	Dir       string            // cache directory
+	TTL       time.Duration     // how long responses are kept
+	Transport http.RoundTripper // used for requests (default: http.DefaultTransport)
+}
+
+// New returns a Transport caching responses in dir for ttl
+func New(dir string, ttl time.Duration) *Transport {
+	return &Transport{Dir: dir, TTL: ttl}
+}
+
+// Client returns an http.Client using the Transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// FromCache is true if the response was read from the cache
+func FromCache(resp *http.Response) bool {
+	return resp.Header.Get(fromCacheHeader) != ""
+}
+
+// Clear removes all cached responses in dir
+func Clear(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+// key returns the file name for the request's cached response
+func key(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+	for _, k := range keyHeaders {
+		io.WriteString(h, k+": "+req.Header.Get(k)+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *Transport) path(req *http.Request) string {
+	k := key(req)
+	return filepath.Join(t.Dir, k[:2], k)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || t.TTL <= 0 {
+		return t.next().RoundTrip(req)
+	}
+	path := t.path(req)
+	if resp, err := t.load(path, req); err == nil {
+		return resp, nil
+	}
+	resp, err := t.next().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	// caching is best effort: errors are ignored. Responses are written to
+	// unique temporary files so concurrent requests don't clobber each other.
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+		if f, err := os.CreateTemp(filepath.Dir(path), "tmp-*"); err == nil {
+			_, err := f.Write(dump)
+			if cerr := f.Close(); err == nil && cerr == nil {
+				err = os.Rename(f.Name(), path)
+			}
+			if err != nil {
+				os.Remove(f.Name())
+			}
+		}
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// load returns the cached response if it exists and hasn't expired
+func (t *Transport) load(path string, req *http.Request) (*http.Response, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(info.ModTime()) > t.TTL {
+		os.Remove(path)
+		return nil, os.ErrNotExist
+	}
+	dump, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set(fromCacheHeader, "1")
+	return resp, nil
+}
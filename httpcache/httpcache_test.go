@@ -0,0 +1,75 @@
+package httpcache_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psu-libraries/oats/httpcache"
+)
+
+func TestTransport(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprintf(w, "response %d for %s", calls, r.Header.Get("Accept"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	cli := httpcache.New(dir, time.Hour).Client()
+
+	get := func(path, accept string) (string, bool) {
+		req, _ := http.NewRequest("GET", srv.URL+path, nil)
+		req.Header.Set("Accept", accept)
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b), httpcache.FromCache(resp)
+	}
+	if body, cached := get("/a", "text/plain"); body != "response 1 for text/plain" || cached {
+		t.Errorf("unexpected first response: %q, cached=%v", body, cached)
+	}
+	if body, cached := get("/a", "text/plain"); body != "response 1 for text/plain" || !cached {
+		t.Errorf("expected cached response, got: %q, cached=%v", body, cached)
+	}
+	// different headers are different requests
+	if body, _ := get("/a", "application/json"); body != "response 2 for application/json" {
+		t.Errorf("unexpected response: %q", body)
+	}
+	// errors aren't cached
+	get("/missing", "")
+	if get("/missing", ""); calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+
+	// expired
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			old := time.Now().Add(-2 * time.Hour)
+			os.Chtimes(path, old, old)
+		}
+		return nil
+	})
+	if body, cached := get("/a", "text/plain"); cached || body != "response 5 for text/plain" {
+		t.Errorf("expected expired response to be refreshed, got %q", body)
+	}
+
+	if err := httpcache.Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := get("/a", "text/plain"); cached {
+		t.Error("expected cache to be cleared")
+	}
+}
@@ -0,0 +1,241 @@
+package mockserver
+
+// Airtable: list, get, create, update, and delete records. Every table in
+// every base exists; tables not in the fixtures start empty. As with
+// Airtable, empty fields (false, "", empty lists) are not returned.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximum page size for record lists
+const airtablePageSize = 100
+
+func tableKey(base, table string) string {
+	return base + "/" + table
+}
+
+// Records returns copies of the records in the Airtable base's table
+func (s *Server) Records(base, table string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var recs []Record
+	for _, rec := range s.tables[tableKey(base, table)] {
+		recs = append(recs, rec.copy(nil))
+	}
+	return recs
+}
+
+// copy returns a copy of the record with only the named fields, or all fields
+// if fields is empty.
+func (rec *Record) copy(fields []string) Record {
+	cp := Record{ID: rec.ID, CreatedTime: rec.CreatedTime, Fields: make(map[string]interface{})}
+	for k, v := range rec.Fields {
+		cp.Fields[k] = v
+	}
+	if len(fields) > 0 {
+		keep := make(map[string]bool)
+		for _, f := range fields {
+			keep[f] = true
+		}
+		for k := range cp.Fields {
+			if !keep[k] {
+				delete(cp.Fields, k)
+			}
+		}
+	}
+	return cp
+}
+
+// compact removes empty values from the fields
+func compact(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	for k, v := range fields {
+		switch v := v.(type) {
+		case nil:
+			delete(fields, k)
+		case bool:
+			if !v {
+				delete(fields, k)
+			}
+		case string:
+			if v == "" {
+				delete(fields, k)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				delete(fields, k)
+			}
+		}
+	}
+	return fields
+}
+
+// airtableError writes an error response in Airtable's format
+func airtableError(w http.ResponseWriter, status int, typ, msg string) {
+	resp := map[string]interface{}{
+		"error": map[string]string{"type": typ, "message": msg},
+	}
+	writeJSON(w, status, resp)
+}
+
+func (s *Server) serveAirtable(w http.ResponseWriter, r *http.Request) {
+	// /airtable/v0/{base}/{table}[/{record}]
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, PathAirtable+"/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		airtableError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+	key := tableKey(parts[0], parts[1])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		for _, rec := range s.tables[key] {
+			if rec.ID == parts[2] {
+				writeJSON(w, http.StatusOK, rec.copy(nil))
+				return
+			}
+		}
+		airtableError(w, http.StatusNotFound, "MODEL_ID_NOT_FOUND", "Could not find a record with ID "+parts[2])
+	case len(parts) == 3:
+		airtableError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", r.Method+" is not supported for records")
+	case r.Method == http.MethodGet:
+		s.listRecords(w, r, key)
+	case r.Method == http.MethodPost:
+		s.writeRecords(w, r, key, true, false)
+	case r.Method == http.MethodPatch:
+		s.writeRecords(w, r, key, false, false)
+	case r.Method == http.MethodPut:
+		s.writeRecords(w, r, key, false, true)
+	case r.Method == http.MethodDelete:
+		s.deleteRecords(w, r, key)
+	default:
+		airtableError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", r.Method+" is not supported")
+	}
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	var filter formula
+	if f := q.Get("filterByFormula"); f != "" {
+		var err error
+		filter, err = parseFormula(f)
+		if err != nil {
+			airtableError(w, http.StatusUnprocessableEntity, "INVALID_FILTER_BY_FORMULA",
+				"The formula for filtering records is invalid: "+err.Error())
+			return
+		}
+	}
+	pageSize := airtablePageSize
+	if n, err := strconv.Atoi(q.Get("pageSize")); err == nil && n > 0 && n < pageSize {
+		pageSize = n
+	}
+	maxRecords, _ := strconv.Atoi(q.Get("maxRecords"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	var matches []*Record
+	for _, rec := range s.tables[key] {
+		if filter == nil || truthy(filter.eval(rec)) {
+			matches = append(matches, rec)
+		}
+	}
+	if maxRecords > 0 && len(matches) > maxRecords {
+		matches = matches[:maxRecords]
+	}
+	resp := struct {
+		Records []Record `json:"records"`
+		Offset  string   `json:"offset,omitempty"`
+	}{Records: []Record{}}
+	for i := offset; i < len(matches) && i < offset+pageSize; i++ {
+		resp.Records = append(resp.Records, matches[i].copy(q["fields[]"]))
+	}
+	if offset+pageSize < len(matches) {
+		resp.Offset = strconv.Itoa(offset + pageSize)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeRecords creates (create), replaces (replace), or updates records
+func (s *Server) writeRecords(w http.ResponseWriter, r *http.Request, key string, create, replace bool) {
+	var body struct {
+		Records []Record `json:"records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		airtableError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: "+err.Error())
+		return
+	}
+	if len(body.Records) == 0 || len(body.Records) > 10 {
+		airtableError(w, http.StatusUnprocessableEntity, "INVALID_RECORDS", "Requests must include 1 to 10 records")
+		return
+	}
+	// check IDs before changing anything
+	var targets []*Record
+	if !create {
+		for _, in := range body.Records {
+			var found *Record
+			for _, rec := range s.tables[key] {
+				if rec.ID == in.ID {
+					found = rec
+					break
+				}
+			}
+			if found == nil {
+				airtableError(w, http.StatusNotFound, "MODEL_ID_NOT_FOUND", "Could not find a record with ID "+in.ID)
+				return
+			}
+			targets = append(targets, found)
+		}
+	}
+	var resp struct {
+		Records []Record `json:"records"`
+	}
+	for i, in := range body.Records {
+		var rec *Record
+		if create {
+			rec = &Record{
+				ID:          s.newID("rec"),
+				Fields:      compact(in.Fields),
+				CreatedTime: time.Now().UTC().Format(time.RFC3339),
+			}
+			s.tables[key] = append(s.tables[key], rec)
+		} else {
+			rec = targets[i]
+			if replace {
+				rec.Fields = make(map[string]interface{})
+			}
+			for k, v := range in.Fields {
+				rec.Fields[k] = v
+			}
+			rec.Fields = compact(rec.Fields)
+		}
+		resp.Records = append(resp.Records, rec.copy(nil))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) deleteRecords(w http.ResponseWriter, r *http.Request, key string) {
+	type deleted struct {
+		ID      string `json:"id"`
+		Deleted bool   `json:"deleted"`
+	}
+	var resp struct {
+		Records []deleted `json:"records"`
+	}
+	for _, id := range r.URL.Query()["records[]"] {
+		recs := s.tables[key]
+		for i, rec := range recs {
+			if rec.ID == id {
+				s.tables[key] = append(recs[:i], recs[i+1:]...)
+				resp.Records = append(resp.Records, deleted{ID: id, Deleted: true})
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
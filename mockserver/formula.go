@@ -0,0 +1,466 @@
+package mockserver
+
+// A subset of Airtable's formula language, used to evaluate filterByFormula
+// in record list requests. Supported are field references ({Name}), string
+// and number literals, the operators = != <> < > <= >= & + - * /, and the
+// functions AND, OR, NOT, IF, LEN, LOWER, UPPER, TRIM, FIND, BLANK, TRUE,
+// FALSE, and RECORD_ID.
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// formula is a parsed Airtable formula
+type formula interface {
+	eval(rec *Record) interface{}
+}
+
+type fieldRef string
+
+type literal struct {
+	val interface{}
+}
+
+type binary struct {
+	op   string
+	l, r formula
+}
+
+type call struct {
+	name string
+	args []formula
+}
+
+// functions maps supported functions to their number of arguments (-1 for
+// any number).
+var functions = map[string]int{
+	"AND":       -1,
+	"OR":        -1,
+	"NOT":       1,
+	"IF":        3,
+	"LEN":       1,
+	"LOWER":     1,
+	"UPPER":     1,
+	"TRIM":      1,
+	"FIND":      2,
+	"BLANK":     0,
+	"TRUE":      0,
+	"FALSE":     0,
+	"RECORD_ID": 0,
+}
+
+func (f fieldRef) eval(rec *Record) interface{} {
+	return rec.Fields[string(f)]
+}
+
+func (l literal) eval(rec *Record) interface{} {
+	return l.val
+}
+
+func (b binary) eval(rec *Record) interface{} {
+	l, r := b.l.eval(rec), b.r.eval(rec)
+	switch b.op {
+	case "&":
+		return toString(l) + toString(r)
+	case "+", "-", "*", "/":
+		x, _ := toNumber(l)
+		y, _ := toNumber(r)
+		switch b.op {
+		case "+":
+			return x + y
+		case "-":
+			return x - y
+		case "*":
+			return x * y
+		}
+		if y == 0 {
+			return math.NaN()
+		}
+		return x / y
+	}
+	return compare(b.op, l, r)
+}
+
+func (c call) eval(rec *Record) interface{} {
+	switch c.name {
+	case "AND":
+		for _, a := range c.args {
+			if !truthy(a.eval(rec)) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		for _, a := range c.args {
+			if truthy(a.eval(rec)) {
+				return true
+			}
+		}
+		return false
+	case "NOT":
+		return !truthy(c.args[0].eval(rec))
+	case "IF":
+		if truthy(c.args[0].eval(rec)) {
+			return c.args[1].eval(rec)
+		}
+		return c.args[2].eval(rec)
+	case "LEN":
+		return float64(len([]rune(toString(c.args[0].eval(rec)))))
+	case "LOWER":
+		return strings.ToLower(toString(c.args[0].eval(rec)))
+	case "UPPER":
+		return strings.ToUpper(toString(c.args[0].eval(rec)))
+	case "TRIM":
+		return strings.TrimSpace(toString(c.args[0].eval(rec)))
+	case "FIND":
+		// 1-based position of the first argument in the second, 0 if
+		// not found
+		needle := toString(c.args[0].eval(rec))
+		hay := toString(c.args[1].eval(rec))
+		i := strings.Index(hay, needle)
+		if i < 0 {
+			return float64(0)
+		}
+		return float64(len([]rune(hay[:i])) + 1)
+	case "TRUE":
+		return true
+	case "FALSE":
+		return false
+	case "RECORD_ID":
+		return rec.ID
+	}
+	return nil // BLANK
+}
+
+// compare compares values as numbers if either is a number or checkbox, and
+// as strings otherwise.
+func compare(op string, l, r interface{}) bool {
+	cmp := 0
+	x, okX := toNumber(l)
+	y, okY := toNumber(r)
+	if (isNumber(l) || isNumber(r)) && okX && okY {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(toString(l), toString(r))
+	}
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp >= 0 // >=
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, bool:
+		return true
+	}
+	return false
+}
+
+// toNumber converts v to a number. Blank values are 0.
+func toNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case nil:
+		return 0, true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toString converts v to a string. Lists (linked records, multiple selects)
+// are joined with ", ".
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case []interface{}:
+		strs := make([]string, len(v))
+		for i := range v {
+			strs[i] = toString(v[i])
+		}
+		return strings.Join(strs, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// truthy returns whether v is true in a logical context
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// formula tokens
+const (
+	tokEOF = iota
+	tokField
+	tokString
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind int
+	val  string
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '{':
+			end := i + 1
+			for end < len(rs) && rs[end] != '}' {
+				end++
+			}
+			if end == len(rs) {
+				return nil, fmt.Errorf("unterminated field name at %d", i)
+			}
+			toks = append(toks, token{tokField, string(rs[i+1 : end])})
+			i = end + 1
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			end := i + 1
+			for ; end < len(rs) && rs[end] != r; end++ {
+				if rs[end] == '\\' && end+1 < len(rs) {
+					end++
+				}
+				b.WriteRune(rs[end])
+			}
+			if end == len(rs) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, token{tokString, b.String()})
+			i = end + 1
+		case unicode.IsDigit(r) || r == '.':
+			end := i
+			for end < len(rs) && (unicode.IsDigit(rs[end]) || rs[end] == '.') {
+				end++
+			}
+			toks = append(toks, token{tokNumber, string(rs[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(rs) && (unicode.IsLetter(rs[end]) || unicode.IsDigit(rs[end]) || rs[end] == '_') {
+				end++
+			}
+			toks = append(toks, token{tokIdent, strings.ToUpper(string(rs[i:end]))})
+			i = end
+		case r == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case r == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case r == ',':
+			toks = append(toks, token{tokComma, ","})
+			i++
+		default:
+			op := string(r)
+			if i+1 < len(rs) {
+				switch two := string(rs[i : i+2]); two {
+				case "!=", "<>", "<=", ">=":
+					op = two
+				}
+			}
+			if !strings.Contains("= != <> < > <= >= & + - * /", op) || op == "!" {
+				return nil, fmt.Errorf("unexpected %q at %d", op, i)
+			}
+			toks = append(toks, token{tokOp, op})
+			i += len([]rune(op))
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+// parser is a recursive descent parser for formulas. From lowest to highest
+// precedence: comparisons, &, + and -, * and /.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// parseFormula parses the formula string
+func parseFormula(s string) (formula, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	f, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", tok.val)
+	}
+	return f, nil
+}
+
+var precedence = map[string]int{
+	"=": 1, "!=": 1, "<>": 1, "<": 1, ">": 1, "<=": 1, ">=": 1,
+	"&": 2,
+	"+": 3, "-": 3,
+	"*": 4, "/": 4,
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// expr parses operators with precedence greater than min
+func (p *parser) expr(min int) (formula, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec := precedence[tok.val]
+		if tok.kind != tokOp || prec <= min {
+			return left, nil
+		}
+		p.next()
+		right, err := p.expr(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: tok.val, l: left, r: right}
+	}
+}
+
+func (p *parser) primary() (formula, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokField:
+		return fieldRef(tok.val), nil
+	case tokString:
+		return literal{tok.val}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(tok.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.val)
+		}
+		return literal{f}, nil
+	case tokLParen:
+		f, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("expected )")
+		}
+		return f, nil
+	case tokOp:
+		if tok.val == "-" {
+			f, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			return binary{op: "-", l: literal{float64(0)}, r: f}, nil
+		}
+	case tokIdent:
+		return p.call(tok.val)
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of formula")
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.val)
+}
+
+func (p *parser) call(name string) (formula, error) {
+	nargs, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported function %s", name)
+	}
+	if p.next().kind != tokLParen {
+		return nil, fmt.Errorf("expected ( after %s", name)
+	}
+	c := call{name: name}
+	if p.peek().kind == tokRParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+			tok := p.next()
+			if tok.kind == tokRParen {
+				break
+			}
+			if tok.kind != tokComma {
+				return nil, fmt.Errorf("expected , or ) in %s", name)
+			}
+		}
+	}
+	if nargs >= 0 && len(c.args) != nargs {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, nargs, len(c.args))
+	}
+	return c, nil
+}
@@ -0,0 +1,54 @@
+package mockserver
+
+import "testing"
+
+func TestFormula(t *testing.T) {
+	rec := &Record{
+		ID: "rec1",
+		Fields: map[string]interface{}{
+			"ID":            "155081269248",
+			"Num":           float64(1234),
+			"DOI":           "10.1093/mnras/staa2325",
+			"DOI_Confirmed": true,
+			"Status":        "To Deposit",
+			"Tasks":         []interface{}{"recA", "recB"},
+		},
+	}
+	table := map[string]bool{
+		`{ID} = '155081269248'`:    true,
+		`{Num} = '1234'`:           true,
+		`{Num} > 1000`:             true,
+		`{Num} <= 1000`:            false,
+		`{Missing} = ""`:           true,
+		`{Status} = ""`:            false,
+		`NOT({DOI_Confirmed})`:     false,
+		`NOT({RMD_Updated})`:       true,
+		`{DOI_Confirmed} = TRUE()`: true,
+		`AND(LEN({DOI})>1,{DOI_Confirmed},{Status} != "Complete")`:   true,
+		`AND(LEN({DOI})>1,{DOI_Confirmed},NOT({Permissions}))`:       true,
+		`AND(LEN({DOI})>1,LEN({ScholarSphere_Link})<4,{Status}="x")`: false,
+		`OR({Status} = "Complete", FIND("10.1093", {DOI}) = 1)`:      true,
+		`IF({DOI_Confirmed}, "yes", "") = "yes"`:                     true,
+		`LOWER({Status}) & "!" = 'to deposit!'`:                      true,
+		`{Tasks} = "recA, recB"`:                                     true,
+		`RECORD_ID() = "rec1"`:                                       true,
+		`{Num} * 2 - 8 / 4 = 2466`:                                   true,
+		`(1 + 2) * 3 = 9`:                                            true,
+		`-{Num} < 0`:                                                 true,
+	}
+	for in, expect := range table {
+		f, err := parseFormula(in)
+		if err != nil {
+			t.Errorf("%s: %s", in, err)
+			continue
+		}
+		if got := truthy(f.eval(rec)); got != expect {
+			t.Errorf("%s: expected %v, got %v", in, expect, got)
+		}
+	}
+	for _, bad := range []string{`{ID`, `"abc`, `AND({A},`, `SUM({A})`, `NOT()`, `{A} == 1`, `{A} 1`} {
+		if _, err := parseFormula(bad); err == nil {
+			t.Errorf("%s: expected parse error", bad)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package mockserver serves in-memory fakes of the APIs used by oats
+// (Airtable, ScholarSphere, RMD, CrossRef, doi.org, DataCite, Unpaywall, and
+// Open Access Button) so that commands can be run locally and tested end to
+// end. Each service is served under its own path prefix (see Endpoints) and
+// is seeded from fixture files (see LoadFixtures). Changes made through the
+// APIs (Airtable updates, deposits, RMD links) are kept in memory and can be
+// inspected with the Server's methods. SMTPServer is a fake SMTP server for
+// commands that send email.
+package mockserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/rmd"
+)
+
+// path prefixes for each service
+const (
+	PathAirtable      = "/airtable/v0"
+	PathScholarSphere = "/scholarsphere"
+	PathRMD           = "/rmd"
+	PathCrossRef      = "/crossref"
+	PathDOI           = "/doi"
+	PathDataCite      = "/datacite"
+	PathUnpaywall     = "/unpaywall/v2"
+	PathOAButton      = "/oabutton"
+)
+
+// Endpoints are base URLs for each service
+type Endpoints struct {
+	Airtable      string
+	ScholarSphere string
+	RMD           string
+	CrossRef      string
+	DOI           string
+	DataCite      string
+	Unpaywall     string
+	OAButton      string
+}
+
+// EndpointsFor returns the Endpoints for a Server at the URL
+func EndpointsFor(serverURL string) Endpoints {
+	u := strings.TrimSuffix(serverURL, "/")
+	return Endpoints{
+		Airtable:      u + PathAirtable,
+		ScholarSphere: u + PathScholarSphere,
+		RMD:           u + PathRMD,
+		CrossRef:      u + PathCrossRef,
+		DOI:           u + PathDOI,
+		DataCite:      u + PathDataCite,
+		Unpaywall:     u + PathUnpaywall,
+		OAButton:      u + PathOAButton,
+	}
+}
+
+// Record is an Airtable record
+type Record struct {
+	ID          string                 `json:"id"`
+	Fields      map[string]interface{} `json:"fields"`
+	CreatedTime string                 `json:"createdTime,omitempty"`
+}
+
+// Fixtures are the initial data for a Server. Works are kept as raw JSON in
+// the format returned by each service.
+type Fixtures struct {
+	// Airtable records by base ID and table name
+	Airtable map[string]map[string][]Record
+	// CrossRef works (the "message" in API responses)
+	CrossRef []json.RawMessage
+	// DataCite works (the "data" in API responses)
+	DataCite []json.RawMessage
+	// Unpaywall responses
+	Unpaywall []json.RawMessage
+	// Open Access Button permissions responses by DOI
+	OAButton map[string]json.RawMessage
+	// RMD publications
+	RMD []rmd.Publication
+	// works already in ScholarSphere: IDs by "doi:" prefixed DOI
+	ScholarSphere map[string][]string
+}
+
+// fixture files in the directory read by LoadFixtures
+var fixtureFiles = map[string]func(*Fixtures) interface{}{
+	"airtable.json":      func(fx *Fixtures) interface{} { return &fx.Airtable },
+	"crossref.json":      func(fx *Fixtures) interface{} { return &fx.CrossRef },
+	"datacite.json":      func(fx *Fixtures) interface{} { return &fx.DataCite },
+	"unpaywall.json":     func(fx *Fixtures) interface{} { return &fx.Unpaywall },
+	"oabutton.json":      func(fx *Fixtures) interface{} { return &fx.OAButton },
+	"rmd.json":           func(fx *Fixtures) interface{} { return &fx.RMD },
+	"scholarsphere.json": func(fx *Fixtures) interface{} { return &fx.ScholarSphere },
+}
+
+// LoadFixtures reads fixtures from JSON files in the directory: airtable.json,
+// crossref.json, datacite.json, unpaywall.json, oabutton.json, rmd.json, and
+// scholarsphere.json. Missing files are skipped.
+func LoadFixtures(dir string) (*Fixtures, error) {
+	fx := &Fixtures{}
+	for name, field := range fixtureFiles {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, field(fx)); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+	}
+	return fx, nil
+}
+
+// Server is an http.Handler for all mock services
+type Server struct {
+	mux *http.ServeMux
+
+	mu        sync.Mutex
+	nextID    int
+	tables    map[string][]*Record // by base/table
+	crossref  map[doi.DOI]json.RawMessage
+	datacite  map[doi.DOI]json.RawMessage
+	unpaywall map[doi.DOI]json.RawMessage
+	oabutton  map[doi.DOI]json.RawMessage
+	rmdPubs   []rmd.Publication
+	rmdLinks  map[string]string   // ScholarSphere links by Activity Insight ID
+	scholDOIs map[string][]string // ScholarSphere IDs by "doi:" DOI
+	uploads   map[string]*upload  // by upload ID
+	deposits  []Deposit
+}
+
+// New returns a Server with data from the Fixtures, which may be nil.
+func New(fx *Fixtures) (*Server, error) {
+	if fx == nil {
+		fx = &Fixtures{}
+	}
+	s := &Server{
+		mux:       http.NewServeMux(),
+		tables:    make(map[string][]*Record),
+		crossref:  make(map[doi.DOI]json.RawMessage),
+		datacite:  make(map[doi.DOI]json.RawMessage),
+		unpaywall: make(map[doi.DOI]json.RawMessage),
+		oabutton:  make(map[doi.DOI]json.RawMessage),
+		rmdPubs:   fx.RMD,
+		rmdLinks:  make(map[string]string),
+		scholDOIs: make(map[string][]string),
+		uploads:   make(map[string]*upload),
+	}
+	for base, tables := range fx.Airtable {
+		for table, recs := range tables {
+			key := tableKey(base, table)
+			for i := range recs {
+				rec := recs[i]
+				if rec.ID == "" {
+					rec.ID = s.newID("rec")
+				}
+				rec.Fields = compact(rec.Fields)
+				s.tables[key] = append(s.tables[key], &rec)
+			}
+		}
+	}
+	works := []struct {
+		name  string
+		raw   []json.RawMessage
+		index map[doi.DOI]json.RawMessage
+		key   string // DOI field
+	}{
+		{"crossref", fx.CrossRef, s.crossref, "DOI"},
+		{"datacite", fx.DataCite, s.datacite, "id"},
+		{"unpaywall", fx.Unpaywall, s.unpaywall, "doi"},
+	}
+	for _, w := range works {
+		for i, raw := range w.raw {
+			var fields map[string]interface{}
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return nil, fmt.Errorf("%s fixture %d: %w", w.name, i, err)
+			}
+			val, _ := fields[w.key].(string)
+			d, err := doi.Parse(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s fixture %d: %w", w.name, i, err)
+			}
+			w.index[d] = raw
+		}
+	}
+	for k, raw := range fx.OAButton {
+		d, err := doi.Parse(k)
+		if err != nil {
+			return nil, fmt.Errorf("oabutton fixture: %w", err)
+		}
+		s.oabutton[d] = raw
+	}
+	for k, ids := range fx.ScholarSphere {
+		s.scholDOIs[k] = append([]string{}, ids...)
+	}
+	s.mux.HandleFunc(PathAirtable+"/", s.serveAirtable)
+	s.mux.HandleFunc(PathScholarSphere+"/", s.serveScholarSphere)
+	s.mux.HandleFunc(PathRMD+"/", s.serveRMD)
+	s.mux.HandleFunc(PathCrossRef+"/", s.serveCrossRef)
+	s.mux.HandleFunc(PathDOI+"/", s.serveDOI)
+	s.mux.HandleFunc(PathDataCite+"/", s.serveDataCite)
+	s.mux.HandleFunc(PathUnpaywall+"/", s.serveUnpaywall)
+	s.mux.HandleFunc(PathOAButton+"/", s.serveOAButton)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// newID returns a new, unique ID with the prefix
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%014d", prefix, s.nextID)
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// pathDOI returns the DOI in the request path after the prefix
+func pathDOI(r *http.Request, prefix string) (doi.DOI, error) {
+	return doi.Parse(strings.TrimPrefix(r.URL.Path, prefix))
+}
@@ -0,0 +1,206 @@
+package mockserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mehanizm/airtable"
+	"github.com/psu-libraries/oats/crossref"
+	"github.com/psu-libraries/oats/datacite"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/mockserver"
+	"github.com/psu-libraries/oats/oabutton"
+	"github.com/psu-libraries/oats/rmd"
+	"github.com/psu-libraries/oats/scholargo"
+	"github.com/psu-libraries/oats/unpaywall"
+)
+
+const base = "appMockBase"
+
+func newServer(t *testing.T) (*mockserver.Server, mockserver.Endpoints) {
+	t.Helper()
+	fx, err := mockserver.LoadFixtures(filepath.Join("testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := mockserver.New(fx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return srv, mockserver.EndpointsFor(ts.URL)
+}
+
+func TestAirtable(t *testing.T) {
+	is := is.New(t)
+	srv, ends := newServer(t)
+	cli := airtable.NewClient("key")
+	is.NoErr(cli.SetBaseURL(ends.Airtable))
+	tasks := cli.GetTable(base, "Tasks")
+
+	recs, err := tasks.GetRecords().
+		WithFilterFormula(`AND(LEN({DOI})>1,{DOI_Confirmed},{Status} != "Complete")`).
+		ReturnFields("Title", "DOI").
+		Do()
+	is.NoErr(err)
+	is.Equal(len(recs.Records), 1)
+	is.Equal(recs.Records[0].ID, "recTask00000001")
+	is.Equal(recs.Records[0].Fields["Status"], nil) // not in returned fields
+
+	// update
+	rec, err := tasks.GetRecord("recTask00000002")
+	is.NoErr(err)
+	_, err = rec.UpdateRecordPartial(map[string]interface{}{"DOI_Confirmed": true, "Status": ""})
+	is.NoErr(err)
+	rec, err = tasks.GetRecord("recTask00000002")
+	is.NoErr(err)
+	is.Equal(rec.Fields["DOI_Confirmed"], true)
+	_, hasStatus := rec.Fields["Status"]
+	is.True(!hasStatus) // empty fields are removed
+
+	// create in a new table
+	added, err := cli.GetTable(base, "DOI Review").AddRecords(&airtable.Records{
+		Records: []*airtable.Record{{Fields: map[string]interface{}{"DOI": "10.1000/abc"}}},
+	})
+	is.NoErr(err)
+	is.Equal(len(added.Records), 1)
+	is.Equal(len(srv.Records(base, "DOI Review")), 1)
+
+	// invalid formula
+	_, err = tasks.GetRecords().WithFilterFormula(`AND({DOI}`).Do()
+	is.True(err != nil)
+}
+
+func TestAirtablePages(t *testing.T) {
+	is := is.New(t)
+	var recs []mockserver.Record
+	for i := 0; i < 250; i++ {
+		recs = append(recs, mockserver.Record{Fields: map[string]interface{}{"N": float64(i)}})
+	}
+	srv, err := mockserver.New(&mockserver.Fixtures{
+		Airtable: map[string]map[string][]mockserver.Record{base: {"Big": recs}},
+	})
+	is.NoErr(err)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	cli := airtable.NewClient("key")
+	is.NoErr(cli.SetBaseURL(mockserver.EndpointsFor(ts.URL).Airtable))
+	cfg := cli.GetTable(base, "Big").GetRecords().WithFilterFormula("{N} >= 10")
+	var total int
+	for {
+		page, err := cfg.Do()
+		is.NoErr(err)
+		total += len(page.Records)
+		if page.Offset == "" {
+			break
+		}
+		cfg.WithOffset(page.Offset)
+	}
+	is.Equal(total, 240)
+}
+
+func TestWorks(t *testing.T) {
+	is := is.New(t)
+	_, ends := newServer(t)
+	d := doi.MustParse("10.1093/mnras/staa2325")
+
+	cr := crossref.NewClient(crossref.Options{BaseURL: ends.CrossRef})
+	cite, err := cr.Work(context.Background(), d)
+	is.NoErr(err)
+	is.Equal(cite.Volume, "498")
+	works, err := cr.Works(context.Background(), []doi.DOI{d, doi.MustParse("10.1093/mnras/staa3102"), doi.MustParse("10.1000/missing")})
+	is.NoErr(err)
+	is.Equal(len(works), 2)
+	found, err := cr.Search(context.Background(), crossref.Query{Bibliographic: "tidal heating icy satellites"})
+	is.NoErr(err)
+	is.True(len(found) > 0)
+	is.Equal(found[0].DOI, "10.1093/mnras/staa3102")
+
+	dc := doi.NewClient(doi.Options{BaseURL: ends.DOI})
+	res := dc.Resolve(context.Background(), d)
+	is.NoErr(res.Err)
+	is.True(res.Resolves())
+	is.Equal(res.Agency, doi.RACrossref)
+	is.True(!dc.Resolve(context.Background(), doi.MustParse("10.1000/missing")).Exists)
+	ra, err := dc.RA(context.Background(), doi.MustParse("10.5061/dryad.8515"))
+	is.NoErr(err)
+	is.Equal(ra, doi.RADataCite)
+
+	dcite, err := datacite.NewClient(datacite.Options{BaseURL: ends.DataCite}).GetCitation(context.Background(), doi.MustParse("10.5061/dryad.8515"))
+	is.NoErr(err)
+	is.Equal(dcite.Publisher, "Dryad")
+
+	up := unpaywall.NewClient(unpaywall.Options{BaseURL: ends.Unpaywall, Email: "oats@example.com"})
+	oa, err := up.GetDOI(context.Background(), d)
+	is.NoErr(err)
+	is.Equal(oa.OAStatus, "green")
+
+	oab := oabutton.NewClient(oabutton.Options{BaseURL: ends.OAButton})
+	perms, err := oab.GetPermissions(context.Background(), d)
+	is.NoErr(err)
+	is.True(perms[0].ScholarSphereOK())
+	_, err = oab.GetPermissions(context.Background(), doi.MustParse("10.1000/missing"))
+	is.Equal(err, oabutton.ErrNotArticle)
+}
+
+func TestRMD(t *testing.T) {
+	is := is.New(t)
+	srv, ends := newServer(t)
+	cli := rmd.NewClient(rmd.Options{BaseURL: ends.RMD, Key: "key"})
+	pubs, err := cli.PublicationsAI(context.Background(), "155081269248")
+	is.NoErr(err)
+	is.Equal(len(pubs), 1)
+	pubs, err = cli.PublicationsDOI(context.Background(), doi.MustParse("10.1093/mnras/staa3102"))
+	is.NoErr(err)
+	is.Equal(len(pubs), 1)
+	pubs, err = cli.UserPublications(context.Background(), "auu4")
+	is.NoErr(err)
+	is.Equal(len(pubs), 2)
+	_, err = cli.UserPublications(context.Background(), "nobody")
+	is.True(err != nil)
+
+	is.NoErr(cli.UpdateScholarSphereLink(context.Background(), "155081269248", "https://example.org/resources/1"))
+	is.Equal(srv.RMDLinks()["155081269248"], "https://example.org/resources/1")
+	is.True(cli.UpdateScholarSphereLink(context.Background(), "000", "x") != nil)
+}
+
+func TestScholarSphere(t *testing.T) {
+	is := is.New(t)
+	srv, ends := newServer(t)
+	cli := scholargo.NewClient(scholargo.Options{BaseURL: ends.ScholarSphere, Key: "key"})
+	dois, err := cli.DOIs(context.Background())
+	is.NoErr(err)
+	is.Equal(len(dois), 1)
+
+	file := filepath.Join(t.TempDir(), "article.pdf")
+	is.NoErr(os.WriteFile(file, []byte("%PDF-1.4\n%%EOF\n"), 0644))
+	meta := &scholargo.WorkMeta{
+		WorkType:      "article",
+		Title:         "Evolution of the giant planet cores",
+		Description:   "Abstract",
+		PublishedDate: "2020-08-05",
+		Visibility:    "open",
+		Rights:        "https://rightsstatements.org/page/InC/1.0/",
+		Identifier:    []string{"10.1093/mnras/staa2325"},
+		Creators:      []scholargo.Creator{{PSUID: "auu4"}},
+	}
+	resp, err := cli.Deposit(context.Background(), meta, "auu4", file)
+	is.NoErr(err)
+	deps := srv.Deposits()
+	is.Equal(len(deps), 1)
+	is.Equal(resp.URL, "/resources/"+deps[0].ID)
+	is.Equal(string(deps[0].Files["article.pdf"]), "%PDF-1.4\n%%EOF\n")
+	dois, err = cli.DOIs(context.Background())
+	is.NoErr(err)
+	is.Equal(len(dois.Find(doi.MustParse("10.1093/mnras/staa2325"))), 1)
+
+	// invalid metadata
+	meta.Rights = "cc-by"
+	_, err = cli.Deposit(context.Background(), meta, "auu4", file)
+	is.True(err != nil)
+}
@@ -0,0 +1,117 @@
+package mockserver
+
+// RMD: publications by Activity Insight ID, DOI, and user, and ScholarSphere
+// link updates.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/rmd"
+)
+
+// RMDLinks returns ScholarSphere links set through the RMD API, by Activity
+// Insight ID.
+func (s *Server) RMDLinks() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	links := make(map[string]string)
+	for k, v := range s.rmdLinks {
+		links[k] = v
+	}
+	return links
+}
+
+func rmdError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, rmd.ServerErr{Code: status, Message: msg})
+}
+
+func (s *Server) serveRMD(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, PathRMD)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case path == "/v1/publications" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		var match func(rmd.Publication) bool
+		switch {
+		case q.Get("activity_insight_id") != "":
+			aiID := q.Get("activity_insight_id")
+			match = func(p rmd.Publication) bool { return hasAIID(p, aiID) }
+		case q.Get("doi") != "":
+			d, err := doi.Parse(q.Get("doi"))
+			if err != nil {
+				rmdError(w, http.StatusBadRequest, "Invalid DOI")
+				return
+			}
+			match = func(p rmd.Publication) bool {
+				pubDOI, _ := doi.Parse(p.Attributes.DOI)
+				return pubDOI == d
+			}
+		default:
+			match = func(rmd.Publication) bool { return true }
+		}
+		s.writePubs(w, match)
+	case path == "/v1/publications" && r.Method == http.MethodPatch:
+		var update struct {
+			AIID string `json:"activity_insight_id"`
+			Link string `json:"scholarsphere_open_access_url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.AIID == "" {
+			rmdError(w, http.StatusUnprocessableEntity, "Missing activity_insight_id")
+			return
+		}
+		found := false
+		for _, p := range s.rmdPubs {
+			found = found || hasAIID(p, update.AIID)
+		}
+		if !found {
+			rmdError(w, http.StatusNotFound, "No publications found with the given Activity Insight ID")
+			return
+		}
+		s.rmdLinks[update.AIID] = update.Link
+		writeJSON(w, http.StatusOK, map[string]string{"message": "ScholarSphere Open Access URL updated successfully"})
+	case strings.HasPrefix(path, "/v1/users/") && strings.HasSuffix(path, "/publications"):
+		user := strings.TrimSuffix(strings.TrimPrefix(path, "/v1/users/"), "/publications")
+		hasUser := func(p rmd.Publication) bool {
+			for _, c := range p.Attributes.Contributors {
+				if strings.EqualFold(c.PSUID, user) {
+					return true
+				}
+			}
+			return false
+		}
+		found := false
+		for _, p := range s.rmdPubs {
+			found = found || hasUser(p)
+		}
+		if !found {
+			rmdError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		s.writePubs(w, hasUser)
+	default:
+		rmdError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) writePubs(w http.ResponseWriter, match func(rmd.Publication) bool) {
+	resp := rmd.PublicationsResponse{Data: []rmd.Publication{}}
+	for _, p := range s.rmdPubs {
+		if match(p) {
+			resp.Data = append(resp.Data, p)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func hasAIID(p rmd.Publication, aiID string) bool {
+	for _, id := range p.Attributes.ActivityInsightIDS {
+		if id == aiID {
+			return true
+		}
+	}
+	return false
+}
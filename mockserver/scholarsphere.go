@@ -0,0 +1,157 @@
+package mockserver
+
+// ScholarSphere: DOIs of existing works, file uploads (including the
+// presigned "S3" URL files are sent to), and ingest.
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+// Deposit is a work created through the ingest API
+type Deposit struct {
+	ID        string             // ScholarSphere resource ID
+	Metadata  scholargo.WorkMeta // deposited metadata
+	Depositor string             // depositor's PSU ID
+	Files     map[string][]byte  // uploaded file contents by filename
+}
+
+// upload is a file upload location
+type upload struct {
+	md5  string // expected Content-MD5
+	data []byte // nil until the file is sent
+}
+
+// Deposits returns works deposited to the mock ScholarSphere
+func (s *Server) Deposits() []Deposit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Deposit{}, s.deposits...)
+}
+
+func (s *Server) serveScholarSphere(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, PathScholarSphere)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case path == "/api/v1/dois" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.scholDOIs)
+	case path == "/api/v1/uploads" && r.Method == http.MethodPost:
+		s.newUpload(w, r)
+	case strings.HasPrefix(path, "/s3/") && r.Method == http.MethodPut:
+		s.putUpload(w, r, strings.TrimPrefix(path, "/s3/"))
+	case path == "/api/v1/ingest" && r.Method == http.MethodPost:
+		s.ingest(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not found"})
+	}
+}
+
+func (s *Server) newUpload(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Extension  string `json:"extension"`
+		ContentMD5 string `json:"content_md5"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Extension == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Bad request: extension is required"})
+		return
+	}
+	id := s.newID("upload") + "." + body.Extension
+	s.uploads[id] = &upload{md5: body.ContentMD5}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"url":    fmt.Sprintf("http://%s%s/s3/%s", r.Host, PathScholarSphere, id),
+		"id":     id,
+		"prefix": "cache",
+	})
+}
+
+func (s *Server) putUpload(w http.ResponseWriter, r *http.Request, id string) {
+	up, ok := s.uploads[id]
+	if !ok {
+		http.Error(w, "NoSuchUpload", http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sum := md5.Sum(data)
+	if up.md5 != "" && base64.StdEncoding.EncodeToString(sum[:]) != up.md5 {
+		http.Error(w, "BadDigest", http.StatusBadRequest)
+		return
+	}
+	up.data = data
+	w.WriteHeader(http.StatusOK)
+}
+
+// ingest creates a work if the metadata is valid and all files have been
+// uploaded.
+func (s *Server) ingest(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Metadata  scholargo.WorkMeta      `json:"metadata"`
+		Content   []struct{ File string } `json:"content"`
+		Depositor string                  `json:"depositor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Bad request: " + err.Error()})
+		return
+	}
+	var errs []string
+	if err := body.Metadata.Validate(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if body.Depositor == "" {
+		errs = append(errs, "depositor is required")
+	}
+	if len(body.Content) == 0 {
+		errs = append(errs, "content is required")
+	}
+	files := make(map[string][]byte)
+	for _, c := range body.Content {
+		var file struct {
+			ID       string `json:"id"`
+			Metadata struct {
+				Filename string `json:"filename"`
+			} `json:"metadata"`
+		}
+		json.Unmarshal([]byte(c.File), &file)
+		up, ok := s.uploads[file.ID]
+		if !ok || up.data == nil {
+			errs = append(errs, "file not uploaded: "+file.ID)
+			continue
+		}
+		files[file.Metadata.Filename] = up.data
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"message": "Unable to complete the request",
+			"errors":  errs,
+		})
+		return
+	}
+	dep := Deposit{
+		ID:        s.newID("resource"),
+		Metadata:  body.Metadata,
+		Depositor: body.Depositor,
+		Files:     files,
+	}
+	s.deposits = append(s.deposits, dep)
+	for _, id := range body.Metadata.Identifier {
+		if d, err := doi.Parse(id); err == nil {
+			s.scholDOIs[d.URN()] = append(s.scholDOIs[d.URN()], dep.ID)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Work successfully created",
+		"url":     "/resources/" + dep.ID,
+	})
+}
@@ -0,0 +1,159 @@
+package mockserver
+
+// SMTPServer is a minimal SMTP server for testing commands that send email.
+// It accepts any sender, recipients, and credentials (AUTH PLAIN), and keeps
+// the messages in memory. It doesn't support TLS.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is an email message received by the SMTPServer
+type Message struct {
+	From string
+	To   []string
+	Data []byte // message headers and body
+}
+
+// SMTPServer is a fake SMTP server. The zero value is ready to use.
+type SMTPServer struct {
+	// OnMessage, if set, is called for each message received
+	OnMessage func(Message)
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Messages returns the messages received so far
+func (s *SMTPServer) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Serve accepts connections on the listener until it is closed
+func (s *SMTPServer) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle runs an SMTP session
+func (s *SMTPServer) handle(conn net.Conn) {
+	tc := textproto.NewConn(conn)
+	defer tc.Close()
+	reply := func(format string, args ...interface{}) bool {
+		return tc.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 mockserver ESMTP") {
+		return
+	}
+	var msg Message
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i > 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-mockserver")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 mockserver")
+		case "AUTH":
+			if fields := strings.Fields(arg); len(fields) == 1 {
+				// credentials on the next line
+				reply("334 ")
+				if _, err := tc.ReadLine(); err != nil {
+					return
+				}
+			}
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg = Message{From: smtpAddr(arg, "FROM:")}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			msg.To = append(msg.To, smtpAddr(arg, "TO:"))
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if msg.From == "" || len(msg.To) == 0 {
+				reply("503 5.5.1 MAIL and RCPT required")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			msg.Data, err = tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			if s.OnMessage != nil {
+				s.OnMessage(msg)
+			}
+			msg = Message{}
+			reply("250 2.0.0 OK: queued")
+		case "RSET":
+			msg = Message{}
+			reply("250 2.0.0 OK")
+		case "NOOP":
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 command not recognized: %s", verb)
+		}
+	}
+}
+
+// smtpAddr returns the address from a MAIL or RCPT argument, e.g.
+// "FROM:<a@example.com> BODY=8BITMIME"
+func smtpAddr(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg = strings.TrimSpace(arg)
+	if i := strings.IndexByte(arg, '>'); strings.HasPrefix(arg, "<") && i > 0 {
+		return arg[1:i]
+	}
+	return strings.Fields(arg + " ")[0]
+}
+
+// Subject returns the message's Subject header
+func (m Message) Subject() string {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(string(m.Data))))
+	h, err := r.ReadMIMEHeader()
+	if err != nil && len(h) == 0 {
+		return ""
+	}
+	subject := h.Get("Subject")
+	if dec, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		return dec
+	}
+	return subject
+}
+
+// String describes the message for logging
+func (m Message) String() string {
+	return fmt.Sprintf("from=%s to=%s subject=%q", m.From, strings.Join(m.To, ","), m.Subject())
+}
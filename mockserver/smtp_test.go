@@ -0,0 +1,33 @@
+package mockserver_test
+
+import (
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/psu-libraries/oats/mockserver"
+)
+
+func TestSMTP(t *testing.T) {
+	is := is.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	defer ln.Close()
+	var srv mockserver.SMTPServer
+	received := make(chan mockserver.Message, 1)
+	srv.OnMessage = func(m mockserver.Message) { received <- m }
+	go srv.Serve(ln)
+
+	auth := smtp.PlainAuth("", "user", "pass", "127.0.0.1")
+	body := "Subject: =?utf-8?q?Caf=C3=A9?=\r\nFrom: oa@example.com\r\n\r\nHello\r\n.leading dot\r\n"
+	err = smtp.SendMail(ln.Addr().String(), auth, "oa@example.com", []string{"a@example.com", "b@example.com"}, []byte(body))
+	is.NoErr(err)
+	msg := <-received
+	is.Equal(msg.From, "oa@example.com")
+	is.Equal(msg.To, []string{"a@example.com", "b@example.com"})
+	is.Equal(msg.Subject(), "Café")
+	is.True(strings.HasSuffix(string(msg.Data), "Hello\n.leading dot\n"))
+	is.Equal(len(srv.Messages()), 1)
+}
@@ -0,0 +1,267 @@
+package mockserver
+
+// Services that return metadata for DOIs: CrossRef, doi.org, DataCite,
+// Unpaywall, and Open Access Button.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/psu-libraries/oats/doi"
+)
+
+// default number of CrossRef results
+const crossrefRows = 20
+
+func (s *Server) serveCrossRef(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, PathCrossRef)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case path == "/works":
+		s.crossrefList(w, r)
+	case strings.HasPrefix(path, "/works/"):
+		d, err := pathDOI(r, PathCrossRef+"/works/")
+		work, ok := s.crossref[d]
+		if err != nil || !ok {
+			http.Error(w, "Resource not found.", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, crossrefMessage("work", work))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func crossrefMessage(typ string, msg interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"status":          "ok",
+		"message-type":    typ,
+		"message-version": "1.0.0",
+		"message":         msg,
+	}
+}
+
+// crossrefList handles works lookups by DOI (filter=doi:...) and
+// bibliographic searches (query.bibliographic and query.author). Searches
+// return works containing any of the query's words, ordered by the share of
+// words found. Other filters are ignored.
+func (s *Server) crossrefList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rows, err := strconv.Atoi(q.Get("rows"))
+	if err != nil || rows <= 0 {
+		rows = crossrefRows
+	}
+	type item struct {
+		work  map[string]interface{}
+		score float64
+	}
+	var items []item
+	var dois []doi.DOI
+	for _, f := range strings.Split(q.Get("filter"), ",") {
+		if strings.HasPrefix(f, "doi:") {
+			if d, err := doi.Parse(strings.TrimPrefix(f, "doi:")); err == nil {
+				dois = append(dois, d)
+			}
+		}
+	}
+	if len(dois) > 0 {
+		for _, d := range dois {
+			if raw, ok := s.crossref[d]; ok {
+				var work map[string]interface{}
+				json.Unmarshal(raw, &work)
+				items = append(items, item{work: work})
+			}
+		}
+	} else {
+		words := queryWords(q.Get("query.bibliographic") + " " + q.Get("query.author") + " " + q.Get("query"))
+		for _, raw := range s.crossref {
+			var work map[string]interface{}
+			json.Unmarshal(raw, &work)
+			text := strings.Join(queryWords(workText(work)), " ")
+			found := 0
+			for _, word := range words {
+				if strings.Contains(" "+text+" ", " "+word+" ") {
+					found++
+				}
+			}
+			if found > 0 {
+				score := 100 * float64(found) / float64(len(words))
+				work["score"] = score
+				items = append(items, item{work: work, score: score})
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].score != items[j].score {
+				return items[i].score > items[j].score
+			}
+			return fmt.Sprint(items[i].work["DOI"]) < fmt.Sprint(items[j].work["DOI"])
+		})
+	}
+	total := len(items)
+	if len(items) > rows {
+		items = items[:rows]
+	}
+	works := []map[string]interface{}{}
+	for _, it := range items {
+		works = append(works, it.work)
+	}
+	writeJSON(w, http.StatusOK, crossrefMessage("work-list", map[string]interface{}{
+		"total-results":  total,
+		"items-per-page": rows,
+		"items":          works,
+	}))
+}
+
+// workText returns the searchable text of a CrossRef work: titles, container
+// titles, and author names.
+func workText(work map[string]interface{}) string {
+	var parts []string
+	for _, k := range []string{"title", "subtitle", "container-title"} {
+		vals, _ := work[k].([]interface{})
+		for _, v := range vals {
+			parts = append(parts, toString(v))
+		}
+	}
+	authors, _ := work["author"].([]interface{})
+	for _, a := range authors {
+		if a, ok := a.(map[string]interface{}); ok {
+			parts = append(parts, toString(a["given"]), toString(a["family"]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// queryWords returns lowercase words of at least 3 letters or digits
+func queryWords(s string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		if len([]rune(w)) >= 3 {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// doi.org handle API response codes
+const (
+	handleOK       = 1
+	handleNotFound = 100
+)
+
+// serveDOI implements doi.org's handle (/api/handles/) and registration
+// agency (/ra/) APIs. DOIs in the CrossRef and DataCite fixtures exist and
+// resolve to https://example.org/{doi}.
+func (s *Server) serveDOI(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, PathDOI)
+	var prefix string
+	switch {
+	case strings.HasPrefix(path, "/api/handles/"):
+		prefix = PathDOI + "/api/handles/"
+	case strings.HasPrefix(path, "/ra/"):
+		prefix = PathDOI + "/ra/"
+	default:
+		// content negotiation is not supported
+		http.NotFound(w, r)
+		return
+	}
+	d, err := pathDOI(r, prefix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	_, isCrossRef := s.crossref[d]
+	_, isDataCite := s.datacite[d]
+	s.mu.Unlock()
+	ra := ""
+	switch {
+	case isCrossRef:
+		ra = doi.RACrossref
+	case isDataCite:
+		ra = doi.RADataCite
+	}
+	if prefix == PathDOI+"/ra/" {
+		if ra == "" {
+			writeJSON(w, http.StatusOK, []map[string]string{{"DOI": d.String(), "status": "DOI does not exist"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, []map[string]string{{"DOI": d.String(), "RA": ra}})
+		return
+	}
+	if ra == "" {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"responseCode": handleNotFound, "handle": d.String()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"responseCode": handleOK,
+		"handle":       d.String(),
+		"values": []map[string]interface{}{{
+			"index": 1,
+			"type":  "URL",
+			"data":  map[string]string{"format": "string", "value": "https://example.org/" + d.String()},
+		}},
+	})
+}
+
+func (s *Server) serveDataCite(w http.ResponseWriter, r *http.Request) {
+	d, err := pathDOI(r, PathDataCite+"/dois/")
+	s.mu.Lock()
+	work, ok := s.datacite[d]
+	s.mu.Unlock()
+	if err != nil || !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"errors": []map[string]string{{"status": "404", "title": "The resource you are looking for doesn't exist."}},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": work})
+}
+
+func (s *Server) serveUnpaywall(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("email") == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"HTTP_status_code": 422, "error": true,
+			"message": "You must provide an email parameter, like ?email=YOUR_EMAIL",
+		})
+		return
+	}
+	d, err := pathDOI(r, PathUnpaywall+"/")
+	s.mu.Lock()
+	rec, ok := s.unpaywall[d]
+	s.mu.Unlock()
+	if err != nil || !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"HTTP_status_code": 404, "error": true,
+			"message": fmt.Sprintf("'%s' isn't in Unpaywall.", d),
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) serveOAButton(w http.ResponseWriter, r *http.Request) {
+	d, err := pathDOI(r, PathOAButton+"/permissions/")
+	if err != nil || !strings.HasPrefix(r.URL.Path, PathOAButton+"/permissions/") {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	perms, ok := s.oabutton[d]
+	s.mu.Unlock()
+	if !ok {
+		// OAB's response for DOIs it doesn't know
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotImplemented)
+		io.WriteString(w, "DOI is not a journal article")
+		return
+	}
+	writeJSON(w, http.StatusOK, perms)
+}
@@ -1,31 +1,50 @@
 package oabutton
 
 import (
+	"context"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 const (
-	baseURL = `https://api.openaccessbutton.org`
+	// DefaultBaseURL is the Open Access Button API
+	DefaultBaseURL = `https://api.openaccessbutton.org`
 )
 
+// Options for NewClient
+type Options struct {
+	BaseURL    string       // default: DefaultBaseURL
+	Key        string       // API key (optional)
+	HTTPClient *http.Client // default: client with a 15 second timeout
+}
+
 // Client is an http client for calling OAB endpoit
 type Client struct {
 	http.Client
-	key string
+	baseURL string
+	key     string
 }
 
 // NewClient returns new OABClient
-func NewClient(k string) *Client {
-	return &Client{
-		Client: http.Client{Timeout: 15 * time.Second},
-		key:    k,
+func NewClient(opts Options) *Client {
+	c := &Client{
+		Client:  http.Client{Timeout: 15 * time.Second},
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		key:     opts.Key,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if opts.HTTPClient != nil {
+		c.Client = *opts.HTTPClient
 	}
+	return c
 }
 
-func (c *Client) newReq(m, u string, b io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(m, u, b)
+func (c *Client) newReq(ctx context.Context, m, u string, b io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, m, u, b)
 	if err != nil {
 		return nil, err
 	}
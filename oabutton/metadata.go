@@ -1,12 +1,12 @@
 package oabutton
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-)
+	"net/url"
 
-const (
-	findURL = baseURL + "/metadata"
+	"github.com/psu-libraries/oats/doi"
 )
 
 // PubMeta data
@@ -33,9 +33,9 @@ type Author struct {
 }
 
 // GetPub returns PubMeta for doi
-func (c *Client) GetPub(doi string) (*PubMeta, error) {
-	url := fmt.Sprintf("%s?id=%s", findURL, doi)
-	req, err := c.newReq(`GET`, url, nil)
+func (c *Client) GetPub(ctx context.Context, d doi.DOI) (*PubMeta, error) {
+	u := fmt.Sprintf("%s/metadata?id=%s", c.baseURL, url.QueryEscape(d.String()))
+	req, err := c.newReq(ctx, `GET`, u, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating metadata request: %w", err)
 
@@ -44,6 +44,7 @@ func (c *Client) GetPub(doi string) (*PubMeta, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error during metadata request: %w", err)
 	}
+	defer resp.Body.Close()
 	pub := PubMeta{}
 	return &pub, json.NewDecoder(resp.Body).Decode(&pub)
 }
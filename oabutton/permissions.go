@@ -2,15 +2,17 @@ package oabutton
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/psu-libraries/oats/doi"
 )
 
 const (
-	permissionsURL   = `https://api.openaccessbutton.org/permissions/`
 	PublishedVersion = "publishedVersion"
 	AcceptedVerstion = "acceptedVersion"
 	SubmittedVersion = "submittedVersion"
@@ -44,12 +46,12 @@ type ArchiveConditions struct {
 
 // GetPermissionsVersion calls the permission endpoint and retirns the ArchiveCondtions
 // object for the article version with the doi
-func (c *Client) GetPermissions(doi string) ([]ArchiveConditions, error) {
+func (c *Client) GetPermissions(ctx context.Context, d doi.DOI) ([]ArchiveConditions, error) {
 	var permResp struct {
 		AllPermissions []ArchiveConditions `json:"all_permissions"`
 		BestPermission ArchiveConditions   `json:"best_permission"`
 	}
-	req, err := c.newReq(http.MethodGet, permissionsURL+doi, nil)
+	req, err := c.newReq(ctx, http.MethodGet, c.baseURL+"/permissions/"+d.Path(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating permissions request: %w", err)
 	}
@@ -57,6 +59,7 @@ func (c *Client) GetPermissions(doi string) ([]ArchiveConditions, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error during permissions request: %w", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		buff := &bytes.Buffer{}
 		io.Copy(buff, resp.Body)
@@ -1,18 +1,26 @@
 package oabutton_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/matryer/is"
+	"github.com/psu-libraries/oats/cassette"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/oabutton"
 )
 
 func TestPermissions(t *testing.T) {
 	is := is.New(t)
-	c := oabutton.NewClient("")
-	perms, err := c.GetPermissions("10.1037/apl0000872")
+	cas := cassette.New(t, "permissions")
+	c := oabutton.NewClient(oabutton.Options{HTTPClient: cas.Client()})
+	perms, err := c.GetPermissions(context.Background(), doi.MustParse("10.1037/apl0000872"))
 	is.NoErr(err)
 	is.True(len(perms) > 0)
 	is.Equal(perms[0].ScholarSphereOK(), true)
 	is.Equal(perms[0].BestLicense(), "other-closed")
+
+	_, err = c.GetPermissions(context.Background(), doi.MustParse("10.1000/not-an-article"))
+	is.True(errors.Is(err, oabutton.ErrNotArticle))
 }
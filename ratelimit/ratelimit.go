@@ -0,0 +1,119 @@
+// Package ratelimit spaces out requests to an API. Limits can be set from
+// X-Rate-Limit-Limit and X-Rate-Limit-Interval response headers (used by
+// CrossRef and others).
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter allows one request per interval. A Limiter is safe for concurrent
+// use; the zero value has no limit.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration // minimum time between requests
+	next     time.Time     // earliest time for the next request
+}
+
+// New returns a Limiter allowing limit requests per period
+func New(limit int, per time.Duration) *Limiter {
+	l := &Limiter{}
+	l.SetRate(limit, per)
+	return l
+}
+
+// SetRate changes the limit to limit requests per period. A limit <= 0
+// removes the limit.
+func (l *Limiter) SetRate(limit int, per time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit <= 0 {
+		l.interval = 0
+		return
+	}
+	l.interval = per / time.Duration(limit)
+}
+
+// Interval returns the minimum time between requests
+func (l *Limiter) Interval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+// Wait blocks until a request may be made or the context is done, in which
+// case it returns the context's error.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next = now.Add(wait + l.interval)
+	l.mu.Unlock()
+	if wait == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Delay postpones the next request by at least d (e.g., for a Retry-After
+// header).
+func (l *Limiter) Delay(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t := time.Now().Add(d); t.After(l.next) {
+		l.next = t
+	}
+}
+
+// Update sets the rate from the response's X-Rate-Limit-Limit and
+// X-Rate-Limit-Interval headers, e.g. "50" and "1s". It returns false if the
+// headers are missing or invalid.
+func (l *Limiter) Update(h http.Header) bool {
+	limit, err := strconv.Atoi(h.Get("X-Rate-Limit-Limit"))
+	if err != nil || limit <= 0 {
+		return false
+	}
+	per, err := time.ParseDuration(h.Get("X-Rate-Limit-Interval"))
+	if err != nil || per <= 0 {
+		return false
+	}
+	l.SetRate(limit, per)
+	return true
+}
+
+// Transport is an http.RoundTripper that waits for the Limiter before each
+// request. Responses served by a cache in front of the Transport don't count
+// against the limit.
+type Transport struct {
+	Limiter   *Limiter
+	Transport http.RoundTripper // default: http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper. It waits for the Limiter using
+// the request's context.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Limiter != nil {
+		if err := t.Limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	next := t.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
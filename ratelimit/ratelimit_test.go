@@ -0,0 +1,110 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/psu-libraries/oats/ratelimit"
+)
+
+func TestUpdate(t *testing.T) {
+	l := ratelimit.New(10, time.Second)
+	if i := l.Interval(); i != 100*time.Millisecond {
+		t.Errorf("expected 100ms interval, got %s", i)
+	}
+	h := http.Header{}
+	h.Set("X-Rate-Limit-Limit", "50")
+	h.Set("X-Rate-Limit-Interval", "1s")
+	if !l.Update(h) || l.Interval() != 20*time.Millisecond {
+		t.Errorf("expected 20ms interval, got %s", l.Interval())
+	}
+	h.Set("X-Rate-Limit-Interval", "soon")
+	if l.Update(h) || l.Interval() != 20*time.Millisecond {
+		t.Errorf("invalid header changed interval to %s", l.Interval())
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := ratelimit.New(100, time.Second) // 10ms apart
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+	if el := time.Since(start); el < 50*time.Millisecond {
+		t.Errorf("6 requests at 10ms intervals took %s", el)
+	}
+	var zero ratelimit.Limiter
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		zero.Wait(context.Background())
+	}
+	if el := time.Since(start); el > 50*time.Millisecond {
+		t.Errorf("zero Limiter should not wait, took %s", el)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	l := ratelimit.New(1, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	cli := &http.Client{Transport: &ratelimit.Transport{Limiter: ratelimit.New(50, time.Second)}}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := cli.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if len(times) != 5 {
+		t.Fatalf("expected 5 requests, got %d", len(times))
+	}
+	first, last := times[0], times[0]
+	for _, tm := range times {
+		if tm.Before(first) {
+			first = tm
+		}
+		if tm.After(last) {
+			last = tm
+		}
+	}
+	if d := last.Sub(first); d < 70*time.Millisecond {
+		t.Errorf("expected requests spread over about 80ms, got %s", d)
+	}
+}
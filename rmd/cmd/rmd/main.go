@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"log"
@@ -27,7 +28,7 @@ func main() {
 	if url == "" {
 		log.Fatal("RMD_URL not set")
 	}
-	cli := rmd.NewClient(url, key)
+	cli := rmd.NewClient(rmd.Options{BaseURL: url, Key: key})
 
 	var query map[string]string
 	if aiID != "" {
@@ -43,7 +44,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	pubs, err := cli.PublicationsRaw(query)
+	pubs, err := cli.PublicationsRaw(context.Background(), query)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -2,12 +2,16 @@ package rmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+
+	"github.com/psu-libraries/oats/doi"
 )
 
 type Client struct {
@@ -69,15 +73,27 @@ type Contributor struct {
 	PSUID      string `json:"psu_user_id"`
 }
 
-func NewClient(url, k string) *Client {
-	return &Client{
-		baseURL: url,
-		key:     k,
+// Options for NewClient
+type Options struct {
+	BaseURL    string       // RMD instance (production or QA)
+	Key        string       // API key
+	HTTPClient *http.Client // default: http.DefaultClient
+}
+
+// NewClient returns a new RMD Client
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		key:     opts.Key,
+	}
+	if opts.HTTPClient != nil {
+		c.Client = *opts.HTTPClient
 	}
+	return c
 }
 
-func (c *Client) newReq(m, u string, b io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(m, u, b)
+func (c *Client) newReq(ctx context.Context, m, u string, b io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, m, u, b)
 	if err != nil {
 		return nil, err
 	}
@@ -89,13 +105,13 @@ func (c *Client) newReq(m, u string, b io.Reader) (*http.Request, error) {
 	return req, nil
 }
 
-func (c *Client) publications(filter map[string]string) ([]Publication, error) {
+func (c *Client) publications(ctx context.Context, filter map[string]string) ([]Publication, error) {
 	query := url.Values{}
 	for k, v := range filter {
 		query.Set(k, v)
 	}
 	u := c.baseURL + "/v1/publications?" + query.Encode()
-	req, err := c.newReq("GET", u, nil)
+	req, err := c.newReq(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -120,13 +136,13 @@ func (c *Client) publications(filter map[string]string) ([]Publication, error) {
 	return pubs.Data, nil
 }
 
-func (c *Client) PublicationsRaw(filter map[string]string) (interface{}, error) {
+func (c *Client) PublicationsRaw(ctx context.Context, filter map[string]string) (interface{}, error) {
 	query := url.Values{}
 	for k, v := range filter {
 		query.Set(k, v)
 	}
 	u := c.baseURL + "/v1/publications?" + query.Encode()
-	req, err := c.newReq("GET", u, nil)
+	req, err := c.newReq(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -151,27 +167,27 @@ func (c *Client) PublicationsRaw(filter map[string]string) (interface{}, error)
 	return raw, nil
 }
 
-func (c *Client) PublicationsAI(aiID string) ([]Publication, error) {
+func (c *Client) PublicationsAI(ctx context.Context, aiID string) ([]Publication, error) {
 	query := map[string]string{
 		"activity_insight_id": aiID,
 	}
-	return c.publications(query)
+	return c.publications(ctx, query)
 }
 
-func (c *Client) PublicationsDOI(doi string) ([]Publication, error) {
+func (c *Client) PublicationsDOI(ctx context.Context, d doi.DOI) ([]Publication, error) {
 	query := map[string]string{
-		"doi": doi,
+		"doi": d.String(),
 	}
-	return c.publications(query)
+	return c.publications(ctx, query)
 }
 
-func (c *Client) UserPublications(user string) ([]Publication, error) {
+func (c *Client) UserPublications(ctx context.Context, user string) ([]Publication, error) {
 	if user == "" {
 		return nil, errors.New("cannot get publications for empty user")
 	}
 
 	url := fmt.Sprintf("%s/v1/users/%s/publications", c.baseURL, user)
-	req, err := c.newReq("GET", url, nil)
+	req, err := c.newReq(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +214,7 @@ func (c *Client) UserPublications(user string) ([]Publication, error) {
 
 // UpdateScholarSpherLink submits a request to update the scholarsphere
 // link for records with the activity insight ID aiID
-func (c *Client) UpdateScholarSphereLink(aiID, link string) error {
+func (c *Client) UpdateScholarSphereLink(ctx context.Context, aiID, link string) error {
 	update := map[string]string{
 		"activity_insight_id":           aiID,
 		"scholarsphere_open_access_url": link,
@@ -207,7 +223,7 @@ func (c *Client) UpdateScholarSphereLink(aiID, link string) error {
 	if err != nil {
 		return err
 	}
-	req, err := c.newReq("PATCH", c.baseURL+"/v1/publications", bytes.NewReader(b))
+	req, err := c.newReq(ctx, "PATCH", c.baseURL+"/v1/publications", bytes.NewReader(b))
 	if err != nil {
 		return fmt.Errorf("new request:%w", err)
 	}
@@ -1,25 +1,33 @@
 package rmd_test
 
 import (
-	"fmt"
-	"log"
+	"context"
 	"os"
 	"testing"
 
+	"github.com/psu-libraries/oats/cassette"
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/rmd"
 )
 
 const env = "RMD_KEY"
 const url = "https://metadata-qa.libraries.psu.edu"
 
-func TestGetUserPubs(t *testing.T) {
-	user := "auu4"
+// newClient returns a client that replays the test's cassette. Recording
+// requires an API key for RMD QA.
+func newClient(t *testing.T, name string) *rmd.Client {
 	key := os.Getenv(env)
-	if key == "" {
-		log.Fatal("environment variable not set:", env)
+	if cassette.Recording() && key == "" {
+		t.Skip("environment variable not set:", env)
 	}
-	cli := rmd.NewClient(url, key)
-	pubs, err := cli.UserPublications(user)
+	cas := cassette.New(t, name)
+	return rmd.NewClient(rmd.Options{BaseURL: url, Key: key, HTTPClient: cas.Client()})
+}
+
+func TestGetUserPubs(t *testing.T) {
+	user := "auu4"
+	cli := newClient(t, "user_pubs")
+	pubs, err := cli.UserPublications(context.Background(), user)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -30,53 +38,59 @@ func TestGetUserPubs(t *testing.T) {
 
 func TestGetAIPubs(t *testing.T) {
 	aiUD := "155081269248"
-	key := os.Getenv(env)
-	if key == "" {
-		log.Fatal("environment variable not set:", env)
-	}
-	cli := rmd.NewClient(url, key)
-	pubs, err := cli.PublicationsAI(aiUD)
+	cli := newClient(t, "ai_pubs")
+	pubs, err := cli.PublicationsAI(context.Background(), aiUD)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(pubs) == 0 {
 		t.Fatal("no publications returned")
 	}
+	if pubs[0].Attributes.CompleteTitle() != "Evolution of the Giant Planet Cores: Accretion and Internal Structure" {
+		t.Errorf("unexpected title: %s", pubs[0].Attributes.CompleteTitle())
+	}
 }
 
 func TestGetDOIPubs(t *testing.T) {
-	doi := "10.1093/mnras/staa2325"
-	key := os.Getenv(env)
-	if key == "" {
-		log.Fatal("environment variable not set:", env)
-	}
-	cli := rmd.NewClient(url, key)
-	pubs, err := cli.PublicationsDOI(doi)
+	d := doi.MustParse("10.1093/mnras/staa2325")
+	cli := newClient(t, "doi_pubs")
+	pubs, err := cli.PublicationsDOI(context.Background(), d)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(pubs) == 0 {
 		t.Fatal("no publications returned")
 	}
+	if got, _ := doi.Parse(pubs[0].Attributes.DOI); got != d {
+		t.Errorf("unexpected DOI: %s", pubs[0].Attributes.DOI)
+	}
 }
 
+func TestServerErr(t *testing.T) {
+	cli := newClient(t, "server_err")
+	_, err := cli.UserPublications(context.Background(), "nobody0")
+	srvErr, ok := err.(*rmd.ServerErr)
+	if !ok {
+		t.Fatalf("expected ServerErr, got %v", err)
+	}
+	if srvErr.Code != 404 {
+		t.Errorf("expected 404, got %d", srvErr.Code)
+	}
+}
+
+// Note: recording this cassette updates RMD QA
 func TestUpdateLink(t *testing.T) {
 	aiUD := "155081269248"
-	key := os.Getenv(env)
-	if key == "" {
-		log.Fatal("environment variable not set:", env)
-	}
-	cli := rmd.NewClient(url, key)
-	err := cli.UpdateScholarSphereLink(aiUD, "nil")
+	cli := newClient(t, "update_link")
+	err := cli.UpdateScholarSphereLink(context.Background(), aiUD, "nil")
 	if err != nil {
 		t.Fatal(err)
 	}
-	pubs, err := cli.PublicationsAI(aiUD)
+	pubs, err := cli.PublicationsAI(context.Background(), aiUD)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(pubs) == 0 {
 		t.Fatal("no publications returned")
 	}
-	fmt.Println()
 }
@@ -1,24 +1,49 @@
 package scholargo
 
 import (
+	"context"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // Client is base object for interacting with ScholarSphere API
 type Client struct {
-	BaseURL string
-	Key     string
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+// Options for NewClient
+type Options struct {
+	BaseURL string // ScholarSphere instance (production or QA)
+	Key     string // API key
+	// HTTPClient is used for all requests, including file uploads, which
+	// may be slow (default: http.DefaultClient).
+	HTTPClient *http.Client
+}
+
+// NewClient returns a new ScholarSphere Client
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		key:     opts.Key,
+		client:  opts.HTTPClient,
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	return c
 }
 
 // NewRequest creates a new request to ScholarSphere API endpoint path.
-func (c *Client) NewRequest(method string, path string, body io.Reader) (*http.Request, error) {
-	url := c.BaseURL + "/api/v1/" + path
-	req, err := http.NewRequest(method, url, body)
+func (c *Client) NewRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	url := c.baseURL + "/api/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("X-API-KEY", c.Key)
+	req.Header.Add("X-API-KEY", c.key)
 	req.Header.Add("Content-Type", "application/json")
 	return req, nil
 }
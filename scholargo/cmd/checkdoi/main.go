@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/psu-libraries/oats/doi"
 	"github.com/psu-libraries/oats/scholargo"
 )
 
@@ -15,28 +17,31 @@ const (
 )
 
 var (
-	Key, URL, doi string
+	Key, URL string
 )
 
 func main() {
 	flag.Parse()
-	doi = flag.Arg(0)
 	Key = os.Getenv(keyEnvVar)
 	URL = os.Getenv(urlEnvVar)
 
-	if doi == "" {
+	if flag.Arg(0) == "" {
 		log.Fatal("missing doi to check")
 	}
+	d, err := doi.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	c := scholargo.Client{Key: Key, BaseURL: URL}
-	dois, err := c.DOIs()
+	c := scholargo.NewClient(scholargo.Options{Key: Key, BaseURL: URL})
+	dois, err := c.DOIs(context.Background())
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to get DOIs: %w", err))
 	}
 
-	ids, exists := dois[doi]
-	if !exists {
-		log.Fatalf("DOI not found %s\n", doi)
+	ids := dois.Find(d)
+	if ids == nil {
+		log.Fatalf("DOI not found %s\n", d)
 	}
 	for _, id := range ids {
 		fmt.Println(id)
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -63,7 +65,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	c := scholargo.Client{Key: Key, BaseURL: URL}
+	c := scholargo.NewClient(scholargo.Options{Key: Key, BaseURL: URL})
 
 	var meta scholargo.WorkMeta
 	metaReader, err := os.Open(metafile)
@@ -76,7 +78,16 @@ func main() {
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to read metadata file: %w", err))
 	}
-	resp, err := c.Deposit(&meta, depositor, files...)
+	if err := meta.Validate(); err != nil {
+		var invalid scholargo.ValidationError
+		if errors.As(err, &invalid) {
+			for _, e := range invalid {
+				log.Println(e.Error())
+			}
+		}
+		log.Fatal(fmt.Errorf("%s: %w", metafile, err))
+	}
+	resp, err := c.Deposit(context.Background(), &meta, depositor, files...)
 	if err != nil {
 		log.Fatal(fmt.Errorf("deposit failed: %w", err))
 	}
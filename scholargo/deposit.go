@@ -2,10 +2,10 @@ package scholargo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 )
 
 // Work metadata sent with deposit
@@ -62,10 +62,10 @@ type content struct {
 	File string `json:"file"`
 }
 
-func (c *Client) Deposit(meta *WorkMeta, depositor string, files ...string) (*DepositResponse, error) {
+func (c *Client) Deposit(ctx context.Context, meta *WorkMeta, depositor string, files ...string) (*DepositResponse, error) {
 	var conts []content
 	for _, f := range files {
-		up, err := c.upload(f)
+		up, err := c.upload(ctx, f)
 		if err != nil {
 			return nil, fmt.Errorf("upload %s failed: %w", f, err)
 		}
@@ -86,11 +86,11 @@ func (c *Client) Deposit(meta *WorkMeta, depositor string, files ...string) (*De
 	if err != nil {
 		return nil, err
 	}
-	req, err := c.NewRequest("POST", "ingest", bytes.NewBuffer(body))
+	req, err := c.NewRequest(ctx, "POST", "ingest", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
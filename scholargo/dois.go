@@ -2,20 +2,38 @@ package scholargo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"strings"
+
+	"github.com/psu-libraries/oats/doi"
 )
 
+// DOIMap maps "doi:" prefixed DOIs to ScholarSphere resource IDs
 type DOIMap map[string][]string
 
-func (c *Client) DOIs() (DOIMap, error) {
-	req, err := c.NewRequest("GET", "dois", nil)
+// Find returns the ScholarSphere IDs for works with the DOI. Keys in the map
+// are compared ignoring case.
+func (m DOIMap) Find(d doi.DOI) []string {
+	if ids, ok := m[d.URN()]; ok {
+		return ids
+	}
+	for k, ids := range m {
+		if strings.EqualFold(k, d.URN()) {
+			return ids
+		}
+	}
+	return nil
+}
+
+func (c *Client) DOIs(ctx context.Context) (DOIMap, error) {
+	req, err := c.NewRequest(ctx, "GET", "dois", nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
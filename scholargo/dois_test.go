@@ -0,0 +1,25 @@
+package scholargo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/psu-libraries/oats/cassette"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+func TestDOIs(t *testing.T) {
+	is := is.New(t)
+	cas := cassette.New(t, "dois")
+	c := scholargo.NewClient(scholargo.Options{
+		BaseURL:    "https://scholarsphere.psu.edu",
+		HTTPClient: cas.Client(),
+	})
+	dois, err := c.DOIs(context.Background())
+	is.NoErr(err)
+	is.Equal(len(dois.Find(doi.MustParse("10.1093/mnras/staa2325"))), 1)
+	is.Equal(len(dois.Find(doi.MustParse("10.1037/apl0000872"))), 2) // case insensitive
+	is.Equal(len(dois.Find(doi.MustParse("10.1000/missing"))), 0)
+}
@@ -0,0 +1,50 @@
+package scholargo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidORCID is returned by ParseORCID for values that are not ORCID iDs
+var ErrInvalidORCID = errors.New("invalid ORCID iD")
+
+// orcid iD with optional URL or "orcid:" prefix; hyphens are optional
+var orcidRE = regexp.MustCompile(`(?i)^(?:(?:https?://)?(?:www\.)?orcid\.org/|orcid:\s*)?(\d{4})-?(\d{4})-?(\d{4})-?(\d{3}[\dX])/?$`)
+
+// ParseORCID parses an ORCID iD in bare (0000-0002-1825-0097), URL
+// (https://orcid.org/0000-0002-1825-0097), or unhyphenated form, verifies its
+// check digit, and returns it in the canonical form used by ScholarSphere:
+// 0000-0002-1825-0097.
+func ParseORCID(s string) (string, error) {
+	m := orcidRE.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidORCID, s)
+	}
+	id := strings.ToUpper(strings.Join(m[1:], "-"))
+	digits := strings.Join(m[1:], "")
+	if orcidCheckDigit(digits[:15]) != id[len(id)-1] {
+		return "", fmt.Errorf("%w: %q has an incorrect check digit", ErrInvalidORCID, s)
+	}
+	return id, nil
+}
+
+// ORCIDURL returns the https://orcid.org URL for a canonical ORCID iD
+func ORCIDURL(id string) string {
+	return "https://orcid.org/" + id
+}
+
+// orcidCheckDigit computes the ISO 7064 11,2 check digit for the first 15
+// digits of an ORCID iD.
+func orcidCheckDigit(base string) byte {
+	total := 0
+	for _, c := range base {
+		total = (total + int(c-'0')) * 2
+	}
+	result := (12 - total%11) % 11
+	if result == 10 {
+		return 'X'
+	}
+	return byte('0' + result)
+}
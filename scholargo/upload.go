@@ -2,6 +2,7 @@ package scholargo
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
@@ -41,12 +42,12 @@ type uploadLocation struct {
 }
 
 // upload the file name and returns completed Upload
-func (c *Client) upload(name string) (*upload, error) {
+func (c *Client) upload(ctx context.Context, name string) (*upload, error) {
 	meta, err := newFileMeta(name)
 	if err != nil {
 		return nil, err
 	}
-	loc, err := c.uploadLocation(meta)
+	loc, err := c.uploadLocation(ctx, meta)
 	if err != nil {
 		return nil, err
 	}
@@ -54,13 +55,13 @@ func (c *Client) upload(name string) (*upload, error) {
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(`PUT`, loc.URL, reader)
+	req, err := http.NewRequestWithContext(ctx, `PUT`, loc.URL, reader)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-MD5", meta.md5)
 	req.ContentLength = meta.Size
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +79,7 @@ func (c *Client) upload(name string) (*upload, error) {
 }
 
 // returns presigned url for uploading file with name.
-func (c *Client) uploadLocation(meta *fileMeta) (*uploadLocation, error) {
+func (c *Client) uploadLocation(ctx context.Context, meta *fileMeta) (*uploadLocation, error) {
 	reqBody := make(map[string]string)
 	if meta.ext == "" {
 		return nil, fmt.Errorf("missing file extension: %s", meta.Filename)
@@ -89,11 +90,11 @@ func (c *Client) uploadLocation(meta *fileMeta) (*uploadLocation, error) {
 	if err != nil {
 		return nil, err
 	}
-	req, err := c.NewRequest(`POST`, "uploads", bytes.NewReader(body))
+	req, err := c.NewRequest(ctx, `POST`, "uploads", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,238 @@
+package scholargo
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkTypes are the work_type values accepted by ScholarSphere
+var WorkTypes = []string{
+	"article",
+	"audio",
+	"book",
+	"capstone_project",
+	"conference_proceeding",
+	"dataset",
+	"dissertation",
+	"image",
+	"journal",
+	"map_or_cartographic_material",
+	"masters_culminating_experience",
+	"masters_thesis",
+	"other",
+	"part_of_book",
+	"poster",
+	"presentation",
+	"project",
+	"report",
+	"research_paper",
+	"software_or_program_code",
+	"thesis",
+	"unspecified",
+	"video",
+}
+
+// Visibilities are the visibility values accepted by ScholarSphere
+var Visibilities = []string{
+	"open",
+	"authenticated",
+	"restricted",
+}
+
+// Licenses are the rights URIs accepted by ScholarSphere
+var Licenses = []string{
+	"https://creativecommons.org/licenses/by/4.0/",
+	"https://creativecommons.org/licenses/by-sa/4.0/",
+	"https://creativecommons.org/licenses/by-nc/4.0/",
+	"https://creativecommons.org/licenses/by-nd/4.0/",
+	"https://creativecommons.org/licenses/by-nc-nd/4.0/",
+	"https://creativecommons.org/licenses/by-nc-sa/4.0/",
+	"http://creativecommons.org/publicdomain/zero/1.0/",
+	"http://creativecommons.org/publicdomain/mark/1.0/",
+	"https://rightsstatements.org/page/InC/1.0/",
+	"https://www.apache.org/licenses/LICENSE-2.0",
+	"https://www.gnu.org/licenses/gpl.html",
+	"https://opensource.org/licenses/MIT",
+	"https://opensource.org/licenses/BSD-3-Clause",
+}
+
+// FieldError describes a problem with a single WorkMeta field
+type FieldError struct {
+	Field   string // json name of the field, e.g. "creators[0].orcid"
+	Value   string // the offending value (may be empty)
+	Problem string
+}
+
+func (e FieldError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Problem)
+	}
+	return fmt.Sprintf("%s: %s (%q)", e.Field, e.Problem, e.Value)
+}
+
+// ValidationError is the list of problems found by WorkMeta.Validate
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return "invalid metadata: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the metadata against ScholarSphere's requirements. It returns
+// nil or a ValidationError listing every problem found.
+func (m *WorkMeta) Validate() error {
+	var errs ValidationError
+	add := func(field, val, problem string) {
+		errs = append(errs, FieldError{Field: field, Value: val, Problem: problem})
+	}
+	// required
+	if m.WorkType == "" {
+		add("work_type", "", "required")
+	} else if !oneOf(m.WorkType, WorkTypes) {
+		add("work_type", m.WorkType, "unknown work type")
+	}
+	if strings.TrimSpace(m.Title) == "" {
+		add("title", "", "required")
+	}
+	if strings.TrimSpace(m.Description) == "" {
+		add("description", "", "required")
+	}
+	if m.Visibility == "" {
+		add("visibility", "", "required")
+	} else if !oneOf(m.Visibility, Visibilities) {
+		add("visibility", m.Visibility, "unknown visibility")
+	}
+	if m.Rights == "" {
+		add("rights", "", "required")
+	} else if !oneOf(m.Rights, Licenses) {
+		add("rights", m.Rights, "unknown license")
+	}
+	if m.PublishedDate == "" {
+		add("published_date", "", "required")
+	} else if !validEDTF(m.PublishedDate) {
+		add("published_date", m.PublishedDate, "not a valid EDTF date")
+	}
+	if len(m.Creators) == 0 {
+		add("creators", "", "at least one creator is required")
+	}
+	for i, c := range m.Creators {
+		field := fmt.Sprintf("creators[%d]", i)
+		if c.Name == "" && c.PSUID == "" && c.Orcid == "" {
+			add(field, "", "creator needs a display_name, psu_id, or orcid")
+		}
+		if c.Orcid != "" {
+			id, err := ParseORCID(c.Orcid)
+			if err != nil {
+				add(field+".orcid", c.Orcid, "not a valid ORCID iD")
+			} else if id != c.Orcid {
+				add(field+".orcid", c.Orcid, "ORCID iD not in the form 0000-0000-0000-0000")
+			}
+		}
+		if c.Email != "" && !strings.Contains(c.Email, "@") {
+			add(field+".email", c.Email, "not an email address")
+		}
+	}
+	// optional
+	if m.Embargo != "" {
+		if _, err := time.Parse("2006-01-02", m.Embargo); err != nil {
+			add("embargoed_until", m.Embargo, "not a valid YYYY-MM-DD date")
+		}
+	}
+	for i, id := range m.Identifier {
+		if !validIdentifier(id) {
+			add(fmt.Sprintf("identifier[%d]", i), id, "not a DOI, ISSN, ISBN, or URL")
+		}
+	}
+	for i, u := range m.RelatedURL {
+		if !validURL(u) {
+			add(fmt.Sprintf("related_url[%d]", i), u, "not an http(s) URL")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func oneOf(val string, allowed []string) bool {
+	for _, a := range allowed {
+		if val == a {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// EDTF date (levels 0 and 1): year with optional month (or season) and
+	// day, X for unspecified digits, and a trailing qualifier.
+	edtfDateRE = regexp.MustCompile(`^(-?[0-9X]{4})(?:-([0-9X]{2})(?:-([0-9X]{2}))?)?[?~%]?$`)
+	doiRE      = regexp.MustCompile(`(?i)^(?:doi:|https?://(?:dx\.)?doi\.org/)?10\.\d{4,9}/\S+$`)
+	issnRE     = regexp.MustCompile(`(?i)^(?:issn:)?\d{4}-?\d{3}[\dX]$`)
+	isbnRE     = regexp.MustCompile(`(?i)^(?:isbn:)?[\d-]{9,16}[\dX]$`)
+)
+
+// validEDTF reports whether s is an EDTF date or interval
+func validEDTF(s string) bool {
+	if parts := strings.SplitN(s, "/", 2); len(parts) == 2 {
+		// open (..) or unknown (empty) ends are allowed, but not both
+		start, end := parts[0], parts[1]
+		if (start == "" || start == "..") && (end == "" || end == "..") {
+			return false
+		}
+		for _, d := range []string{start, end} {
+			if d != "" && d != ".." && !validEDTF(d) {
+				return false
+			}
+		}
+		return true
+	}
+	m := edtfDateRE.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	month, day := m[2], m[3]
+	if month != "" && !strings.Contains(month, "X") {
+		n, _ := strconv.Atoi(month)
+		season := n >= 21 && n <= 24
+		if (n < 1 || n > 12) && !season {
+			return false
+		}
+		if season && day != "" {
+			return false
+		}
+	}
+	if day != "" && !strings.Contains(day, "X") {
+		n, _ := strconv.Atoi(day)
+		if n < 1 || n > 31 {
+			return false
+		}
+		if !strings.ContainsAny(m[1]+month, "X-") {
+			date := fmt.Sprintf("%s-%s-%s", m[1], month, day)
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validIdentifier(id string) bool {
+	id = strings.TrimSpace(id)
+	return doiRE.MatchString(id) || issnRE.MatchString(id) || isbnRE.MatchString(id) || validURL(id)
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
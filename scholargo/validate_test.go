@@ -0,0 +1,101 @@
+package scholargo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/psu-libraries/oats/scholargo"
+)
+
+func validMeta() *scholargo.WorkMeta {
+	return &scholargo.WorkMeta{
+		WorkType:      "article",
+		Title:         "Article Title",
+		Description:   "Abstract...",
+		PublishedDate: "2020-03",
+		Visibility:    "open",
+		Embargo:       "2025-01-01",
+		Rights:        "https://creativecommons.org/licenses/by/4.0/",
+		Identifier:    []string{"10.1093/mnras/staa3102"},
+		Creators: []scholargo.Creator{
+			{Orcid: "0000-0002-1825-0097", Name: "Josiah Carberry"}, {PSUID: "sre53"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(validMeta().Validate())
+
+	meta := validMeta()
+	meta.WorkType = "paper"
+	meta.Visibility = ""
+	meta.Rights = "cc-by"
+	meta.PublishedDate = "2020-13"
+	meta.Embargo = "Jan 1, 2025"
+	meta.Creators[0].Orcid = "0000-0002-1825-0098"
+	meta.Identifier = []string{"not an id"}
+	err := meta.Validate()
+	var invalid scholargo.ValidationError
+	is.True(errors.As(err, &invalid))
+	fields := map[string]bool{}
+	for _, e := range invalid {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"work_type", "visibility", "rights", "published_date",
+		"embargoed_until", "creators[0].orcid", "identifier[0]"} {
+		is.True(fields[f]) // expected a problem with field
+	}
+	is.Equal(len(invalid), 7)
+}
+
+func TestValidateDates(t *testing.T) {
+	table := map[string]bool{
+		`2020`:             true,
+		`2020-02-29`:       true,
+		`2019-02-29`:       false,
+		`2020-21`:          true,
+		`2020-21-01`:       false,
+		`201X`:             true,
+		`2020-XX`:          true,
+		`2020?`:            true,
+		`2004-06~`:         true,
+		`2019/2020-05`:     true,
+		`../2020`:          true,
+		`../..`:            false,
+		`May 2020`:         false,
+		`2020-5-1`:         false,
+		`2020-05-01T10:00`: false,
+	}
+	for in, expect := range table {
+		meta := validMeta()
+		meta.PublishedDate = in
+		if got := meta.Validate() == nil; got != expect {
+			t.Errorf(`for %s, expected %v, got %v`, in, expect, got)
+		}
+	}
+}
+
+func TestParseORCID(t *testing.T) {
+	table := map[string]string{
+		`0000-0002-1825-0097`:                     `0000-0002-1825-0097`,
+		`http://orcid.org/0000-0002-1825-0097`:    `0000-0002-1825-0097`,
+		`https://orcid.org/0000-0002-1694-233x`:   `0000-0002-1694-233X`,
+		` orcid.org/0000-0002-1825-0097/ `:        `0000-0002-1825-0097`,
+		`0000000218250097`:                        `0000-0002-1825-0097`,
+		`https://orcid.org/0000-0002-1825-0098`:   ``,
+		`https://example.org/0000-0002-1825-0097`: ``,
+		`0000-0002-1825`:                          ``,
+		``:                                        ``,
+	}
+	for in, expect := range table {
+		out, err := scholargo.ParseORCID(in)
+		if out != expect {
+			t.Errorf(`for %q, expected %q, got %q`, in, expect, out)
+		}
+		if expect == "" && !errors.Is(err, scholargo.ErrInvalidORCID) {
+			t.Errorf(`for %q, expected ErrInvalidORCID, got %v`, in, err)
+		}
+	}
+}
@@ -1,16 +1,27 @@
 package unpaywall
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/httpcache"
+	"github.com/psu-libraries/oats/ratelimit"
 )
 
 const (
-	baseURL     = `https://api.unpaywall.org/v2`
-	minDelay    = time.Millisecond * 250
+	// DefaultBaseURL is the Unpaywall API
+	DefaultBaseURL = `https://api.unpaywall.org/v2`
+	// MinDelay is the minimum time between requests with the default
+	// HTTPClient
+	MinDelay    = time.Millisecond * 250
 	maxRequests = 100_000
 )
 
@@ -32,39 +43,62 @@ type DOIResp struct {
 	} `json:"best_oa_location"`
 }
 
-// Client is an http client for calling OAB endpoit
+// Client is an http client for calling the Unpaywall API. A Client is safe
+// for concurrent use.
 type Client struct {
+	requestCount int64 // requests sent to Unpaywall (atomic; first for alignment)
 	http.Client
-	email        string
-	requestCount int
-	lastRequest  time.Time
+	baseURL string
+	email   string
+}
+
+// Options for NewClient
+type Options struct {
+	BaseURL string // default: DefaultBaseURL
+	Email   string // contact email, required by Unpaywall
+	// HTTPClient is used for requests. The default waits MinDelay between
+	// requests; other clients should limit requests themselves (see
+	// ratelimit.Transport).
+	HTTPClient *http.Client
 }
 
-// NewClient returns new OABClient
-func NewClient(email string) *Client {
-	return &Client{
-		email: email,
+// NewClient returns new Unpaywall Client
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		email:   opts.Email,
 	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if opts.HTTPClient != nil {
+		c.Client = *opts.HTTPClient
+	} else {
+		c.Client.Transport = &ratelimit.Transport{Limiter: ratelimit.New(1, MinDelay)}
+	}
+	return c
 }
 
-func (c *Client) GetDOI(doi string) (*DOIResp, error) {
-	if c.requestCount > maxRequests {
+// GetDOI returns Unpaywall's record for the DOI
+func (c *Client) GetDOI(ctx context.Context, d doi.DOI) (*DOIResp, error) {
+	if atomic.LoadInt64(&c.requestCount) > maxRequests {
 		return nil, errors.New(`too many requests to unpaywall`)
 	}
-	url := fmt.Sprintf("%s/%s?email=%s", baseURL, doi, c.email)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	u := fmt.Sprintf("%s/%s?email=%s", c.baseURL, d.Path(), url.QueryEscape(c.email))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
-	time.Sleep(time.Until(c.lastRequest.Add(minDelay)))
-	c.lastRequest = time.Now()
-	c.requestCount++
 	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	// cached responses don't count against the limits
+	if !httpcache.FromCache(resp) {
+		atomic.AddInt64(&c.requestCount, 1)
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf(`HTTP Status: %s`, resp.Status)
 	}
@@ -0,0 +1,28 @@
+package unpaywall_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/psu-libraries/oats/cassette"
+	"github.com/psu-libraries/oats/doi"
+	"github.com/psu-libraries/oats/unpaywall"
+)
+
+func TestGetDOI(t *testing.T) {
+	is := is.New(t)
+	cas := cassette.New(t, "get_doi")
+	c := unpaywall.NewClient(unpaywall.Options{
+		Email:      "oats@example.com",
+		HTTPClient: cas.Client(),
+	})
+	resp, err := c.GetDOI(context.Background(), doi.MustParse("10.1093/mnras/staa2325"))
+	is.NoErr(err)
+	is.Equal(resp.OAStatus, "green")
+	is.Equal(resp.Year, 2020)
+	is.Equal(resp.BestOALink.HostType, "repository")
+
+	_, err = c.GetDOI(context.Background(), doi.MustParse("10.1000/does-not-exist"))
+	is.True(err != nil) // expected error for unknown DOI
+}